@@ -13,10 +13,13 @@ import (
 
 	"link-anime/internal/api"
 	"link-anime/internal/auth"
+	"link-anime/internal/cleanup"
 	"link-anime/internal/config"
 	"link-anime/internal/database"
+	"link-anime/internal/diskspace"
 	"link-anime/internal/monitor"
 	"link-anime/internal/notify"
+	"link-anime/internal/nyaa"
 	"link-anime/internal/qbit"
 	"link-anime/internal/rss"
 	"link-anime/internal/scanner"
@@ -48,6 +51,7 @@ func main() {
 
 	// Initialize video extension matcher
 	scanner.InitVideoExtensions(cfg.VideoExtensions)
+	nyaa.SetBaseURL(cfg.NyaaURL)
 
 	// Create WebSocket hub
 	hub := ws.NewHub()
@@ -62,8 +66,34 @@ func main() {
 	// This ensures credentials saved via the Settings UI survive container restarts.
 	server.ReinitClients()
 
+	// Create disk space watcher (checks free space every 5m, notifies when low)
+	diskWatcher := diskspace.NewWatcher(
+		server.DiskRoots,
+		server.MinFreeSpace,
+		func() *notify.Notifier { return server.Notifier },
+		5*time.Minute,
+	)
+	diskWatcher.Start()
+	defer diskWatcher.Stop()
+	server.Disk = diskWatcher
+
+	qbitCategory := func() string {
+		// Prefer DB setting over config
+		if v, err := database.GetSetting("qbit_category"); err == nil && v != "" {
+			return v
+		}
+		return cfg.QbitCategory
+	}
+
 	// Create RSS poller (getter func reads server.Qbit so reinitClients updates are reflected)
-	poller := rss.NewPoller(hub, func() *qbit.Client { return server.Qbit }, 15*time.Minute)
+	poller := rss.NewPoller(
+		hub,
+		func() *qbit.Client { return server.Qbit },
+		qbitCategory,
+		func() *notify.Notifier { return server.Notifier },
+		server.LibraryDirs,
+		diskWatcher.HasSpace,
+	)
 	poller.Start()
 	defer poller.Stop()
 	server.Poller = poller
@@ -73,18 +103,32 @@ func main() {
 		hub,
 		func() *qbit.Client { return server.Qbit },
 		func() *notify.Notifier { return server.Notifier },
-		func() string {
-			// Prefer DB setting over config
-			if v, err := database.GetSetting("qbit_category"); err == nil && v != "" {
-				return v
-			}
-			return cfg.QbitCategory
-		},
+		qbitCategory,
 		5*time.Second,
 	)
+	// Link completed RSS downloads for rules with auto-link enabled
+	dlMonitor.OnComplete = rss.NewAutoLinker(hub, server.LibraryDirs).HandleComplete
+	// Also watch categories RSS rules send grabs to
+	dlMonitor.ExtraCategories = func() []string {
+		cats, err := rss.RuleCategories()
+		if err != nil {
+			log.Printf("[monitor] %v", err)
+		}
+		return cats
+	}
 	dlMonitor.Start()
 	defer dlMonitor.Stop()
 
+	// Create torrent cleaner (removes seeded torrents already in the library, when enabled)
+	cleaner := cleanup.NewCleaner(
+		func() *qbit.Client { return server.Qbit },
+		func() *notify.Notifier { return server.Notifier },
+		server.LibraryDirs,
+	)
+	cleaner.Start()
+	defer cleaner.Stop()
+	server.Cleaner = cleaner
+
 	// Embed frontend static files
 	var staticFS http.FileSystem
 	dist, err := fs.Sub(frontendFS, "dist")
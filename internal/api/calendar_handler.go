@@ -0,0 +1,67 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"link-anime/internal/auth"
+	"link-anime/internal/ical"
+	"link-anime/internal/rss"
+)
+
+// handleCalendarFeed serves expected episodes and recent grabs as an
+// iCalendar feed. It's public but requires the calendar token, since
+// calendar apps can't hold a session.
+func (s *Server) handleCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	if !auth.CheckCalendarToken(r.URL.Query().Get("token")) {
+		jsonError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	days := 30
+	if v := r.URL.Query().Get("days"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 && n <= 365 {
+			days = n
+		}
+	}
+
+	events, err := rss.CalendarEvents(days)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="link-anime.ics"`)
+	if err := ical.Write(w, "link-anime", events); err != nil {
+		log.Printf("[calendar] write feed: %v", err)
+	}
+}
+
+// handleGetCalendarToken returns the calendar token and feed path.
+func (s *Server) handleGetCalendarToken(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.CalendarToken()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonOK(w, calendarTokenResponse(token))
+}
+
+// handleRotateCalendarToken replaces the calendar token, breaking old subscriptions.
+func (s *Server) handleRotateCalendarToken(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.RotateCalendarToken()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonOK(w, calendarTokenResponse(token))
+}
+
+func calendarTokenResponse(token string) map[string]string {
+	return map[string]string{
+		"token": token,
+		"path":  "/api/calendar.ics?token=" + token,
+	}
+}
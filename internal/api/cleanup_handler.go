@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"link-anime/internal/cleanup"
+	"link-anime/internal/models"
+)
+
+// handleCleanupPreview lists completed torrents and whether each can be removed.
+func (s *Server) handleCleanupPreview(w http.ResponseWriter, r *http.Request) {
+	if s.Cleaner == nil {
+		jsonError(w, "cleanup not initialized", http.StatusBadRequest)
+		return
+	}
+
+	candidates, err := s.Cleaner.Preview()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if candidates == nil {
+		jsonOK(w, []interface{}{})
+		return
+	}
+
+	jsonOK(w, candidates)
+}
+
+// handleCleanupRun removes every eligible torrent now.
+func (s *Server) handleCleanupRun(w http.ResponseWriter, r *http.Request) {
+	if s.Cleaner == nil {
+		jsonError(w, "cleanup not initialized", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.Cleaner.Run()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonOK(w, result)
+}
+
+// handleGetCleanupPolicies returns the per-category seeding policies.
+func (s *Server) handleGetCleanupPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := cleanup.LoadPolicies()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonOK(w, policies)
+}
+
+// handleUpdateCleanupPolicies replaces the per-category seeding policies.
+func (s *Server) handleUpdateCleanupPolicies(w http.ResponseWriter, r *http.Request) {
+	var policies []models.CleanupPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policies); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := cleanup.SavePolicies(policies); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonOK(w, map[string]bool{"ok": true})
+}
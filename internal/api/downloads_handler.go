@@ -1,15 +1,27 @@
 package api
 
 import (
+	"encoding/json"
+	"log"
 	"net/http"
+	"path/filepath"
+	"strings"
 
+	"link-anime/internal/downloads"
+	"link-anime/internal/models"
 	"link-anime/internal/parser"
 	"link-anime/internal/scanner"
 )
 
 func (s *Server) handleGetDownloads(w http.ResponseWriter, r *http.Request) {
 	downloadDir := s.getDownloadDir()
-	items, err := scanner.ScanDownloads(downloadDir)
+	filter, err := downloads.Filter(r.URL.Query().Get("state"), downloadDir)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, err := scanner.ScanDownloads(downloadDir, filter)
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -20,9 +32,50 @@ func (s *Server) handleGetDownloads(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if isTruthy(r.URL.Query().Get("enrich")) {
+		s.enrichDownloads(items)
+	}
+
 	jsonOK(w, items)
 }
 
+// enrichDownloads adds parse results, library matches, link status and
+// qBittorrent state to each download item.
+func (s *Server) enrichDownloads(items []models.DownloadItem) {
+	idx := scanner.BuildLibraryIndex(s.getMediaDir(), s.getMoviesDir())
+
+	// Index torrents by name so items can be matched to their torrent.
+	// A torrent's name is its root folder, or the file name for single-file
+	// torrents, unless it was renamed in qBittorrent; its content path still
+	// ends in the name on disk.
+	torrents := make(map[string]models.TorrentStatus)
+	if s.Qbit != nil && s.Qbit.IsConfigured() {
+		list, err := s.Qbit.ListTorrents("")
+		if err != nil {
+			log.Printf("[downloads] failed to list torrents for enrichment: %v", err)
+		}
+		for _, t := range list {
+			torrents[t.Name] = t
+		}
+		for _, t := range list {
+			if t.ContentPath == "" {
+				continue
+			}
+			base := filepath.Base(t.ContentPath)
+			if _, ok := torrents[base]; !ok {
+				torrents[base] = t
+			}
+		}
+	}
+
+	for i := range items {
+		scanner.EnrichDownload(&items[i], idx)
+		if t, ok := torrents[items[i].Name]; ok {
+			items[i].Torrent = &t
+		}
+	}
+}
+
 func (s *Server) handleParseRelease(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	if name == "" {
@@ -36,3 +89,133 @@ func (s *Server) handleParseRelease(w http.ResponseWriter, r *http.Request) {
 		"season": result.Season,
 	})
 }
+
+// handleListDownloadStates returns all stored item states.
+func (s *Server) handleListDownloadStates(w http.ResponseWriter, r *http.Request) {
+	states, err := downloads.ListStates()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if states == nil {
+		jsonOK(w, []interface{}{})
+		return
+	}
+	jsonOK(w, states)
+}
+
+// handleSetDownloadState marks one or more items as ignored, archived or linked elsewhere.
+func (s *Server) handleSetDownloadState(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Paths []string `json:"paths"`
+		State string   `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Paths) == 0 {
+		jsonError(w, "paths is required", http.StatusBadRequest)
+		return
+	}
+	if !downloads.ValidState(req.State) {
+		jsonError(w, "state must be 'ignored', 'archived' or 'linked_elsewhere'", http.StatusBadRequest)
+		return
+	}
+
+	if err := downloads.SetState(req.Paths, req.State); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonOK(w, map[string]bool{"ok": true})
+}
+
+// handleClearDownloadState returns one or more items to the active state.
+func (s *Server) handleClearDownloadState(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Paths []string `json:"paths"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Paths) == 0 {
+		jsonError(w, "paths is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := downloads.ClearState(req.Paths); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonOK(w, map[string]bool{"ok": true})
+}
+
+// handleListIgnorePatterns returns all ignore patterns.
+func (s *Server) handleListIgnorePatterns(w http.ResponseWriter, r *http.Request) {
+	patterns, err := downloads.ListPatterns()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if patterns == nil {
+		jsonOK(w, []interface{}{})
+		return
+	}
+	jsonOK(w, patterns)
+}
+
+// handleAddIgnorePattern adds a glob ignore pattern.
+func (s *Server) handleAddIgnorePattern(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Pattern string `json:"pattern"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	pattern, err := downloads.AddPattern(req.Pattern)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonOK(w, pattern)
+}
+
+// handleDeleteIgnorePattern removes an ignore pattern.
+func (s *Server) handleDeleteIgnorePattern(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if req.ID == 0 {
+		jsonError(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := downloads.DeletePattern(req.ID); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonOK(w, map[string]bool{"ok": true})
+}
+
+// isTruthy interprets common boolean query parameter values.
+func isTruthy(v string) bool {
+	switch strings.ToLower(v) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
@@ -1,7 +1,10 @@
 package api
 
 import (
+	"strconv"
+
 	"link-anime/internal/database"
+	"link-anime/internal/diskspace"
 	"link-anime/internal/notify"
 	"link-anime/internal/qbit"
 	"link-anime/internal/shoko"
@@ -31,6 +34,34 @@ func (s *Server) getMoviesDir() string {
 	return s.Config.MoviesDir
 }
 
+// LibraryDirs returns the download, media and movies directories.
+func (s *Server) LibraryDirs() (download, media, movies string) {
+	return s.getDownloadDir(), s.getMediaDir(), s.getMoviesDir()
+}
+
+// DiskRoots returns the configured download, media and movies directories.
+func (s *Server) DiskRoots() []diskspace.Root {
+	return []diskspace.Root{
+		{Name: "download", Path: s.getDownloadDir()},
+		{Name: "media", Path: s.getMediaDir()},
+		{Name: "movies", Path: s.getMoviesDir()},
+	}
+}
+
+// MinFreeSpace returns the low-space threshold in bytes, preferring DB setting over config.
+func (s *Server) MinFreeSpace() uint64 {
+	gb := s.Config.MinFreeSpaceGB
+	if v, err := database.GetSetting("min_free_space_gb"); err == nil && v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			gb = n
+		}
+	}
+	if gb <= 0 {
+		return 0
+	}
+	return uint64(gb) << 30
+}
+
 // newQbitClient creates a new qBittorrent client.
 func newQbitClient(url, user, pass string) *qbit.Client {
 	return qbit.New(url, user, pass)
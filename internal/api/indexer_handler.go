@@ -0,0 +1,229 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"link-anime/internal/indexer"
+	"link-anime/internal/models"
+)
+
+const maskedAPIKey = "********"
+
+// handleListIndexers returns the configured indexers, API keys masked.
+func (s *Server) handleListIndexers(w http.ResponseWriter, r *http.Request) {
+	configs, err := indexer.List()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if configs == nil {
+		jsonOK(w, []interface{}{})
+		return
+	}
+	for i := range configs {
+		maskIndexer(&configs[i])
+	}
+	jsonOK(w, configs)
+}
+
+// handleCreateIndexer adds an indexer.
+func (s *Server) handleCreateIndexer(w http.ResponseWriter, r *http.Request) {
+	var cfg models.IndexerConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := indexer.Validate(&cfg); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := indexer.Create(&cfg); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	maskIndexer(&cfg)
+	jsonOK(w, cfg)
+}
+
+// handleUpdateIndexer updates an indexer. A masked API key keeps the stored one.
+func (s *Server) handleUpdateIndexer(w http.ResponseWriter, r *http.Request) {
+	var cfg models.IndexerConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if cfg.ID == 0 {
+		jsonError(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	existing, err := indexer.Get(cfg.ID)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if existing == nil {
+		jsonError(w, "indexer not found", http.StatusNotFound)
+		return
+	}
+	if cfg.APIKey == maskedAPIKey {
+		cfg.APIKey = existing.APIKey
+	}
+
+	if err := indexer.Validate(&cfg); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := indexer.Update(&cfg); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	maskIndexer(&cfg)
+	jsonOK(w, cfg)
+}
+
+// handleDeleteIndexer deletes an indexer.
+func (s *Server) handleDeleteIndexer(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if req.ID == 0 {
+		jsonError(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := indexer.Delete(req.ID); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonOK(w, map[string]bool{"ok": true})
+}
+
+// handleTestIndexer checks a saved indexer. Torznab indexers report their
+// caps; Nyaa indexers run a small search.
+func (s *Server) handleTestIndexer(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := indexer.Get(req.ID)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if cfg == nil {
+		jsonError(w, "indexer not found", http.StatusNotFound)
+		return
+	}
+	ix, err := indexer.New(*cfg)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if tz, ok := ix.(*indexer.Torznab); ok {
+		caps, err := tz.Caps()
+		if err != nil {
+			jsonError(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		jsonOK(w, map[string]interface{}{"ok": true, "caps": caps})
+		return
+	}
+
+	results, err := ix.Search(indexer.Query{Text: ""})
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	jsonOK(w, map[string]interface{}{"ok": true, "results": len(results)})
+}
+
+// handleSearch searches the enabled indexers, or those listed in indexers
+// (comma-separated IDs), and merges the results. season and episode narrow
+// Torznab tv-searches. Indexers that failed are listed in failed. With
+// group=true, groups replace results; see groupOptions.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := indexer.Query{Text: q.Get("q")}
+	if query.Text == "" {
+		jsonError(w, "q parameter required", http.StatusBadRequest)
+		return
+	}
+	for name, dest := range map[string]**int{"season": &query.Season, "episode": &query.Episode} {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				jsonError(w, "invalid "+name, http.StatusBadRequest)
+				return
+			}
+			*dest = &n
+		}
+	}
+
+	var ids []int64
+	if v := q.Get("indexers"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				jsonError(w, "indexers must be comma-separated IDs", http.StatusBadRequest)
+				return
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	grouped, profile, err := groupOptions(q)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	indexers, err := indexer.Enabled(ids)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results, failed, err := indexer.Search(indexers, query)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if grouped {
+		jsonOK(w, map[string]interface{}{
+			"groups": indexer.Group(results, profile, s.libraryEpisodes),
+			"failed": failed,
+		})
+		return
+	}
+
+	jsonOK(w, map[string]interface{}{
+		"results": results,
+		"failed":  failed,
+	})
+}
+
+func maskIndexer(cfg *models.IndexerConfig) {
+	if cfg.APIKey != "" {
+		cfg.APIKey = maskedAPIKey
+	}
+}
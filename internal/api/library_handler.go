@@ -3,6 +3,7 @@ package api
 import (
 	"net/http"
 
+	"link-anime/internal/diskspace"
 	"link-anime/internal/scanner"
 )
 
@@ -41,11 +42,17 @@ func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
 		totalEpisodes += show.Episodes
 	}
 
+	disk := diskspace.Report(s.DiskRoots())
+	if s.Disk != nil {
+		disk.Low = s.Disk.IsLow()
+	}
+
 	jsonOK(w, map[string]interface{}{
 		"shows":    len(shows),
 		"seasons":  totalSeasons,
 		"episodes": totalEpisodes,
 		"movies":   len(movies),
 		"size":     size,
+		"disk":     disk,
 	})
 }
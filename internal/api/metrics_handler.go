@@ -0,0 +1,15 @@
+package api
+
+import (
+	"net/http"
+
+	"link-anime/internal/httpx"
+)
+
+// handleHTTPMetrics returns outbound request counts and failures per client and host.
+func (s *Server) handleHTTPMetrics(w http.ResponseWriter, r *http.Request) {
+	jsonOK(w, map[string]interface{}{
+		"proxied": httpx.Proxy() != "",
+		"stats":   httpx.Metrics(),
+	})
+}
@@ -1,26 +1,66 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
+	"link-anime/internal/indexer"
+	"link-anime/internal/models"
 	"link-anime/internal/nyaa"
+	"link-anime/internal/quality"
+	"link-anime/internal/scanner"
+	"link-anime/internal/torrent"
 )
 
+// handleNyaaSearch searches Nyaa. Besides q and filter it takes category,
+// user, sort (date, seeders, leechers, downloads, size, comments), order
+// (asc, desc) and pages. With group=true results are grouped by episode, see
+// groupOptions.
 func (s *Server) handleNyaaSearch(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		jsonError(w, "q parameter required", http.StatusBadRequest)
+	q := r.URL.Query()
+	opts := nyaa.SearchOptions{
+		Query:    q.Get("q"),
+		Category: q.Get("category"),
+		Filter:   q.Get("filter"),
+		User:     q.Get("user"),
+		Sort:     q.Get("sort"),
+		Order:    q.Get("order"),
+	}
+	if opts.Query == "" && opts.User == "" {
+		jsonError(w, "q or user parameter required", http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("pages"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > nyaa.MaxPages {
+			jsonError(w, fmt.Sprintf("pages must be 1 to %d", nyaa.MaxPages), http.StatusBadRequest)
+			return
+		}
+		opts.Pages = n
+	}
+	if err := opts.Validate(); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	grouped, profile, err := groupOptions(q)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	filter := r.URL.Query().Get("filter")
-
-	results, err := nyaa.Search(query, filter)
+	results, err := nyaa.SearchWith(opts)
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if grouped {
+		jsonOK(w, indexer.Group(results, profile, s.libraryEpisodes))
+		return
+	}
+
 	if results == nil {
 		jsonOK(w, []interface{}{})
 		return
@@ -29,6 +69,93 @@ func (s *Server) handleNyaaSearch(w http.ResponseWriter, r *http.Request) {
 	jsonOK(w, results)
 }
 
+// handleNyaaCategories lists the Nyaa categories searches accept.
+func (s *Server) handleNyaaCategories(w http.ResponseWriter, r *http.Request) {
+	jsonOK(w, nyaa.Categories)
+}
+
+// handleNyaaPreview fetches a Nyaa torrent's metainfo and shows its files and
+// how they would be linked. The torrent is given by id, url or infoHash; name,
+// season and type override what is parsed from the torrent name.
+func (s *Server) handleNyaaPreview(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	ref := q.Get("id")
+	if ref == "" {
+		ref = q.Get("url")
+	}
+	if ref == "" {
+		ref = q.Get("infoHash")
+	}
+	if ref == "" {
+		jsonError(w, "id, url or infoHash parameter required", http.StatusBadRequest)
+		return
+	}
+
+	req := models.LinkRequest{Name: q.Get("name"), Type: q.Get("type")}
+	if req.Type != "" && req.Type != "series" && req.Type != "movie" {
+		jsonError(w, "type must be series or movie", http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("season"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			jsonError(w, "invalid season", http.StatusBadRequest)
+			return
+		}
+		req.Season = n
+	}
+
+	id, err := nyaa.TorrentID(ref)
+	if err != nil {
+		status := http.StatusBadRequest
+		if q.Get("infoHash") != "" {
+			status = http.StatusBadGateway // the lookup is a search
+		}
+		jsonError(w, err.Error(), status)
+		return
+	}
+	data, err := nyaa.FetchTorrent(id)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	meta, err := torrent.Parse(data)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	jsonOK(w, torrent.Plan(meta, req))
+}
+
+// groupOptions reads the grouping parameters: group=true groups results by
+// show, season and episode, and profile=<id> ranks releases by that quality
+// profile instead of by resolution alone.
+func groupOptions(q url.Values) (bool, *models.QualityProfile, error) {
+	grouped, _ := strconv.ParseBool(q.Get("group"))
+	v := q.Get("profile")
+	if !grouped || v == "" {
+		return grouped, nil, nil
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return false, nil, fmt.Errorf("invalid profile")
+	}
+	profile, err := quality.Get(id)
+	if err != nil {
+		return false, nil, err
+	}
+	if profile == nil {
+		return false, nil, fmt.Errorf("quality profile %d not found", id)
+	}
+	return true, profile, nil
+}
+
+// libraryEpisodes looks up the episodes of a show's season in the library.
+func (s *Server) libraryEpisodes(show string, season int) map[int]bool {
+	return scanner.LibraryEpisodes(s.getMediaDir(), show, season)
+}
+
 func (s *Server) handleShokoScan(w http.ResponseWriter, r *http.Request) {
 	if s.Shoko == nil || !s.Shoko.IsConfigured() {
 		jsonError(w, "Shoko not configured", http.StatusBadRequest)
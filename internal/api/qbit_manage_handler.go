@@ -0,0 +1,229 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"link-anime/internal/qbit"
+)
+
+// infoHashRe matches v1 (SHA-1) and v2 (SHA-256) info hashes.
+var infoHashRe = regexp.MustCompile(`^(?:[0-9a-fA-F]{40}|[0-9a-fA-F]{64})$`)
+
+// torrentActionRequest carries the parameters of every torrent action; each
+// action reads only the fields it needs.
+type torrentActionRequest struct {
+	Hashes []string `json:"hashes"` // bulk routes only
+
+	Category   *string  `json:"category"`
+	AddTags    []string `json:"addTags"`
+	RemoveTags []string `json:"removeTags"`
+
+	DownloadLimit *int64 `json:"downloadLimit"` // bytes/s, 0 = unlimited
+	UploadLimit   *int64 `json:"uploadLimit"`
+
+	RatioLimit               *float64 `json:"ratioLimit"` // -2 = global, -1 = unlimited
+	SeedingTimeLimit         *int     `json:"seedingTimeLimit"`
+	InactiveSeedingTimeLimit *int     `json:"inactiveSeedingTimeLimit"`
+
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Position string `json:"position"` // top, bottom, increase, decrease
+}
+
+// errBadAction marks a torrent action rejected before reaching qBittorrent.
+var errBadAction = errors.New("invalid torrent action")
+
+func badAction(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errBadAction, fmt.Sprintf(format, args...))
+}
+
+// handleQbitTorrentAction applies an action to the torrent named in the URL.
+func (s *Server) handleQbitTorrentAction(w http.ResponseWriter, r *http.Request) {
+	var req torrentActionRequest
+	if !s.decodeTorrentAction(w, r, &req) {
+		return
+	}
+	s.runTorrentAction(w, chi.URLParam(r, "action"), []string{chi.URLParam(r, "hash")}, req)
+}
+
+// handleQbitBulkAction applies an action to every torrent in the body's hashes.
+func (s *Server) handleQbitBulkAction(w http.ResponseWriter, r *http.Request) {
+	var req torrentActionRequest
+	if !s.decodeTorrentAction(w, r, &req) {
+		return
+	}
+	if len(req.Hashes) == 0 {
+		jsonError(w, "hashes is required", http.StatusBadRequest)
+		return
+	}
+	s.runTorrentAction(w, chi.URLParam(r, "action"), req.Hashes, req)
+}
+
+// decodeTorrentAction checks qBittorrent is configured and reads the optional body.
+func (s *Server) decodeTorrentAction(w http.ResponseWriter, r *http.Request, req *torrentActionRequest) bool {
+	if s.Qbit == nil || !s.Qbit.IsConfigured() {
+		jsonError(w, "qBittorrent not configured", http.StatusBadRequest)
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func (s *Server) runTorrentAction(w http.ResponseWriter, action string, hashes []string, req torrentActionRequest) {
+	for _, h := range hashes {
+		if !infoHashRe.MatchString(h) {
+			jsonError(w, fmt.Sprintf("invalid hash %q", h), http.StatusBadRequest)
+			return
+		}
+	}
+	for i := range hashes {
+		hashes[i] = strings.ToLower(hashes[i])
+	}
+
+	if err := applyTorrentAction(s.Qbit, action, hashes, req); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errBadAction) {
+			status = http.StatusBadRequest
+		}
+		jsonError(w, err.Error(), status)
+		return
+	}
+	jsonOK(w, map[string]interface{}{"ok": true, "hashes": hashes})
+}
+
+// applyTorrentAction validates req for action and sends it to qBittorrent.
+func applyTorrentAction(c *qbit.Client, action string, hashes []string, req torrentActionRequest) error {
+	switch action {
+	case "pause":
+		return c.Pause(hashes)
+	case "resume":
+		return c.Resume(hashes)
+	case "recheck":
+		return c.Recheck(hashes)
+	case "reannounce":
+		return c.Reannounce(hashes)
+
+	case "category":
+		if req.Category == nil {
+			return badAction("category is required")
+		}
+		return c.SetCategory(hashes, strings.TrimSpace(*req.Category))
+
+	case "tags":
+		add, remove := cleanTags(req.AddTags), cleanTags(req.RemoveTags)
+		if len(add) == 0 && len(remove) == 0 {
+			return badAction("addTags or removeTags is required")
+		}
+		if len(add) > 0 {
+			if err := c.AddTags(hashes, add); err != nil {
+				return err
+			}
+		}
+		if len(remove) > 0 {
+			return c.RemoveTags(hashes, remove)
+		}
+		return nil
+
+	case "limits":
+		return applyLimits(c, hashes, req)
+
+	case "rename":
+		if len(hashes) != 1 {
+			return badAction("rename takes a single torrent")
+		}
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			return badAction("name is required")
+		}
+		return c.Rename(hashes[0], name)
+
+	case "location":
+		location := strings.TrimSpace(req.Location)
+		if location == "" {
+			return badAction("location is required")
+		}
+		return c.SetLocation(hashes, location)
+
+	case "priority":
+		switch req.Position {
+		case qbit.QueueTop, qbit.QueueBottom, qbit.QueueIncrease, qbit.QueueDecrease:
+			return c.SetQueuePosition(hashes, req.Position)
+		}
+		return badAction("position must be top, bottom, increase or decrease")
+	}
+	return badAction("unknown action %q", action)
+}
+
+// applyLimits sets whichever speed and share limits the request includes.
+// Share limits are sent together, so omitted ones fall back to the global limit.
+func applyLimits(c *qbit.Client, hashes []string, req torrentActionRequest) error {
+	share := req.RatioLimit != nil || req.SeedingTimeLimit != nil || req.InactiveSeedingTimeLimit != nil
+	if req.DownloadLimit == nil && req.UploadLimit == nil && !share {
+		return badAction("no limits given")
+	}
+	for _, l := range []*int64{req.DownloadLimit, req.UploadLimit} {
+		if l != nil && *l < 0 {
+			return badAction("speed limits cannot be negative")
+		}
+	}
+
+	ratio := float64(qbit.ShareLimitGlobal)
+	seeding, inactive := qbit.ShareLimitGlobal, qbit.ShareLimitGlobal
+	if req.RatioLimit != nil {
+		ratio = *req.RatioLimit
+		if ratio < 0 && ratio != qbit.ShareLimitGlobal && ratio != qbit.ShareLimitUnlimited {
+			return badAction("ratioLimit must be -2, -1 or at least 0")
+		}
+	}
+	for _, m := range []struct {
+		v   *int
+		dst *int
+	}{{req.SeedingTimeLimit, &seeding}, {req.InactiveSeedingTimeLimit, &inactive}} {
+		if m.v == nil {
+			continue
+		}
+		if *m.v < qbit.ShareLimitGlobal {
+			return badAction("time limits must be -2, -1 or at least 0")
+		}
+		*m.dst = *m.v
+	}
+
+	if req.DownloadLimit != nil {
+		if err := c.SetDownloadLimit(hashes, *req.DownloadLimit); err != nil {
+			return err
+		}
+	}
+	if req.UploadLimit != nil {
+		if err := c.SetUploadLimit(hashes, *req.UploadLimit); err != nil {
+			return err
+		}
+	}
+	if share {
+		return c.SetShareLimits(hashes, ratio, seeding, inactive)
+	}
+	return nil
+}
+
+// cleanTags splits comma-separated entries, trims them and drops empty ones.
+func cleanTags(tags []string) []string {
+	var out []string
+	for _, t := range tags {
+		for _, part := range strings.Split(t, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
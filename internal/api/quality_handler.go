@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"link-anime/internal/models"
+	"link-anime/internal/quality"
+)
+
+// handleListQualityProfiles returns all quality profiles.
+func (s *Server) handleListQualityProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := quality.List()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if profiles == nil {
+		jsonOK(w, []interface{}{})
+		return
+	}
+	jsonOK(w, profiles)
+}
+
+// handleCreateQualityProfile creates a new quality profile.
+func (s *Server) handleCreateQualityProfile(w http.ResponseWriter, r *http.Request) {
+	var profile models.QualityProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := quality.Validate(&profile); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := quality.Create(&profile); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonOK(w, profile)
+}
+
+// handleUpdateQualityProfile updates an existing quality profile.
+func (s *Server) handleUpdateQualityProfile(w http.ResponseWriter, r *http.Request) {
+	var profile models.QualityProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if profile.ID == 0 {
+		jsonError(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := quality.Validate(&profile); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := quality.Update(&profile); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonOK(w, profile)
+}
+
+// handleDeleteQualityProfile deletes a quality profile; rules using it fall back to no profile.
+func (s *Server) handleDeleteQualityProfile(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if req.ID == 0 {
+		jsonError(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := quality.Delete(req.ID); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonOK(w, map[string]bool{"ok": true})
+}
@@ -5,7 +5,9 @@ import (
 	"strings"
 
 	"link-anime/internal/auth"
+	"link-anime/internal/cleanup"
 	"link-anime/internal/config"
+	"link-anime/internal/diskspace"
 	"link-anime/internal/notify"
 	"link-anime/internal/qbit"
 	"link-anime/internal/rss"
@@ -24,6 +26,21 @@ type Server struct {
 	Shoko    *shoko.Client
 	Notifier *notify.Notifier
 	Poller   *rss.Poller
+	Disk     *diskspace.Watcher
+	Cleaner  *cleanup.Cleaner
+}
+
+// redactToken hides a token query parameter (the calendar feed's credential)
+// from the request log. Handlers read r.URL, which is left intact.
+func redactToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Has("token") {
+			q.Set("token", "REDACTED")
+			r = r.WithContext(r.Context())
+			r.RequestURI = r.URL.EscapedPath() + "?" + q.Encode()
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
 // NewRouter creates the chi router with all routes and middleware.
@@ -32,6 +49,7 @@ func NewRouter(s *Server, staticFS http.FileSystem) chi.Router {
 
 	// Global middleware
 	r.Use(middleware.RealIP)
+	r.Use(redactToken)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Compress(5))
@@ -42,6 +60,7 @@ func NewRouter(s *Server, staticFS http.FileSystem) chi.Router {
 		r.Post("/auth/login", s.handleLogin)
 		r.Post("/auth/logout", s.handleLogout)
 		r.Get("/auth/check", s.handleAuthCheck)
+		r.Get("/calendar.ics", s.handleCalendarFeed) // token in query string, redacted from logs
 
 		// Protected routes
 		r.Group(func(r chi.Router) {
@@ -55,6 +74,12 @@ func NewRouter(s *Server, staticFS http.FileSystem) chi.Router {
 			// Downloads
 			r.Get("/downloads", s.handleGetDownloads)
 			r.Get("/downloads/parse", s.handleParseRelease)
+			r.Get("/downloads/states", s.handleListDownloadStates)
+			r.Post("/downloads/state", s.handleSetDownloadState)
+			r.Delete("/downloads/state", s.handleClearDownloadState)
+			r.Get("/downloads/ignore-patterns", s.handleListIgnorePatterns)
+			r.Post("/downloads/ignore-patterns", s.handleAddIgnorePattern)
+			r.Delete("/downloads/ignore-patterns", s.handleDeleteIgnorePattern)
 
 			// Link operations
 			r.Post("/link", s.handleLink)
@@ -77,9 +102,30 @@ func NewRouter(s *Server, staticFS http.FileSystem) chi.Router {
 			r.Post("/qbit/add", s.handleQbitAdd)
 			r.Delete("/qbit/delete", s.handleQbitDelete)
 			r.Get("/qbit/test", s.handleQbitTest)
+			r.Post("/qbit/torrents/{action}", s.handleQbitBulkAction)
+			r.Post("/qbit/torrents/{hash}/{action}", s.handleQbitTorrentAction)
+
+			// Outbound HTTP
+			r.Get("/http/metrics", s.handleHTTPMetrics)
+
+			// Torrent cleanup
+			r.Get("/cleanup/preview", s.handleCleanupPreview)
+			r.Post("/cleanup/run", s.handleCleanupRun)
+			r.Get("/cleanup/policies", s.handleGetCleanupPolicies)
+			r.Put("/cleanup/policies", s.handleUpdateCleanupPolicies)
 
 			// Nyaa
 			r.Get("/nyaa/search", s.handleNyaaSearch)
+			r.Get("/nyaa/categories", s.handleNyaaCategories)
+			r.Get("/nyaa/preview", s.handleNyaaPreview)
+
+			// Indexers
+			r.Get("/search", s.handleSearch)
+			r.Get("/indexers", s.handleListIndexers)
+			r.Post("/indexers", s.handleCreateIndexer)
+			r.Put("/indexers", s.handleUpdateIndexer)
+			r.Delete("/indexers", s.handleDeleteIndexer)
+			r.Post("/indexers/test", s.handleTestIndexer)
 
 			// Shoko
 			r.Post("/shoko/scan", s.handleShokoScan)
@@ -88,13 +134,30 @@ func NewRouter(s *Server, staticFS http.FileSystem) chi.Router {
 			// RSS Rules
 			r.Get("/rss/rules", s.handleListRSSRules)
 			r.Get("/rss/rule", s.handleGetRSSRule)
+			r.Get("/rss/rules/completed", s.handleListCompletedRSSRules)
+			r.Get("/rss/rules/export", s.handleExportRSSRules)
+			r.Post("/rss/rules/import", s.handleImportRSSRules)
+			r.Post("/rss/rules/import/qbit", s.handleImportQbitRSSRules)
 			r.Post("/rss/rules", s.handleCreateRSSRule)
 			r.Put("/rss/rules", s.handleUpdateRSSRule)
 			r.Delete("/rss/rules", s.handleDeleteRSSRule)
 			r.Post("/rss/rules/toggle", s.handleToggleRSSRule)
+			r.Post("/rss/rules/test", s.handleTestRSSRule)
 			r.Get("/rss/matches", s.handleListRSSMatches)
 			r.Delete("/rss/matches", s.handleClearRSSMatches)
+			r.Post("/rss/matches/retry", s.handleRetryRSSMatch)
 			r.Post("/rss/poll", s.handleRSSPollNow)
+			r.Get("/rss/schedule", s.handleRSSSchedule)
+
+			// Calendar feed token
+			r.Get("/calendar/token", s.handleGetCalendarToken)
+			r.Post("/calendar/token/rotate", s.handleRotateCalendarToken)
+
+			// Quality profiles
+			r.Get("/quality/profiles", s.handleListQualityProfiles)
+			r.Post("/quality/profiles", s.handleCreateQualityProfile)
+			r.Put("/quality/profiles", s.handleUpdateQualityProfile)
+			r.Delete("/quality/profiles", s.handleDeleteQualityProfile)
 
 			// WebSocket
 			r.Get("/ws", s.handleWS)
@@ -2,8 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"link-anime/internal/models"
 	"link-anime/internal/rss"
@@ -23,6 +25,30 @@ func (s *Server) handleListRSSRules(w http.ResponseWriter, r *http.Request) {
 	jsonOK(w, rules)
 }
 
+// handleListCompletedRSSRules returns the archive of rules that completed automatically.
+func (s *Server) handleListCompletedRSSRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := rss.ListCompletedRules()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rules == nil {
+		jsonOK(w, []interface{}{})
+		return
+	}
+	jsonOK(w, rules)
+}
+
+// handleRSSSchedule returns the next expected episode of each scheduled rule.
+func (s *Server) handleRSSSchedule(w http.ResponseWriter, r *http.Request) {
+	entries, err := rss.Schedule()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonOK(w, entries)
+}
+
 // handleGetRSSRule returns a single RSS rule.
 func (s *Server) handleGetRSSRule(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Query().Get("id")
@@ -45,16 +71,23 @@ func (s *Server) handleGetRSSRule(w http.ResponseWriter, r *http.Request) {
 	jsonOK(w, rule)
 }
 
-// handleCreateRSSRule creates a new RSS rule.
+// handleCreateRSSRule creates a new RSS rule. An optional "backfill" mode
+// decides what happens to items already in the feed, and the response then
+// wraps the rule with a backfill summary. Without it the next poll handles
+// current items like any new item.
 func (s *Server) handleCreateRSSRule(w http.ResponseWriter, r *http.Request) {
-	var rule models.RSSRule
-	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+	var req struct {
+		models.RSSRule
+		Backfill string `json:"backfill"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		jsonError(w, "invalid request", http.StatusBadRequest)
 		return
 	}
+	rule := req.RSSRule
 
-	if rule.Name == "" || rule.Query == "" || rule.ShowName == "" {
-		jsonError(w, "name, query, and showName are required", http.StatusBadRequest)
+	if rule.Name == "" || rule.ShowName == "" {
+		jsonError(w, "name and showName are required", http.StatusBadRequest)
 		return
 	}
 
@@ -65,12 +98,56 @@ func (s *Server) handleCreateRSSRule(w http.ResponseWriter, r *http.Request) {
 		rule.Season = 1
 	}
 
+	if err := rss.ValidateRule(&rule); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Backfill == "" {
+		if err := rss.CreateRule(&rule); err != nil {
+			jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		jsonOK(w, rule)
+		return
+	}
+
+	if !rss.ValidBackfill(req.Backfill) {
+		jsonError(w, "backfill must be missing, batch or none", http.StatusBadRequest)
+		return
+	}
+	if s.Poller == nil {
+		jsonError(w, "RSS poller not initialized", http.StatusBadRequest)
+		return
+	}
+
+	// Keep the rule disabled until backfill has recorded the current items,
+	// so a poll in between can't grab them
+	enabled := rule.Enabled
+	rule.Enabled = false
 	if err := rss.CreateRule(&rule); err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	jsonOK(w, rule)
+	result, err := s.Poller.Backfill(rule, req.Backfill)
+	if err != nil {
+		jsonError(w, fmt.Sprintf("rule created but left disabled, backfill failed: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	if enabled {
+		if err := rss.ToggleRule(rule.ID, true); err != nil {
+			jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rule.Enabled = true
+	}
+
+	jsonOK(w, map[string]interface{}{
+		"rule":     rule,
+		"backfill": result,
+	})
 }
 
 // handleUpdateRSSRule updates an existing RSS rule.
@@ -86,6 +163,11 @@ func (s *Server) handleUpdateRSSRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := rss.ValidateRule(&rule); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if err := rss.UpdateRule(&rule); err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -94,6 +176,38 @@ func (s *Server) handleUpdateRSSRule(w http.ResponseWriter, r *http.Request) {
 	jsonOK(w, rule)
 }
 
+// handleTestRSSRule dry-runs an unsaved rule against its live feed.
+func (s *Server) handleTestRSSRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.RSSRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if rule.MediaType == "" {
+		rule.MediaType = "series"
+	}
+	if rule.Season == 0 && rule.MediaType == "series" {
+		rule.Season = 1
+	}
+
+	if err := rss.ValidateRule(&rule); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, err := rss.TestRule(rule)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if items == nil {
+		items = []models.RSSTestItem{}
+	}
+
+	jsonOK(w, items)
+}
+
 // handleDeleteRSSRule deletes an RSS rule.
 func (s *Server) handleDeleteRSSRule(w http.ResponseWriter, r *http.Request) {
 	var req struct {
@@ -199,6 +313,39 @@ func (s *Server) handleClearRSSMatches(w http.ResponseWriter, r *http.Request) {
 	jsonOK(w, map[string]bool{"ok": true})
 }
 
+// handleRetryRSSMatch re-sends a pending or failed match, optionally with a new magnet.
+func (s *Server) handleRetryRSSMatch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     int64  `json:"id"`
+		Magnet string `json:"magnet"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if req.ID == 0 {
+		jsonError(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	if s.Poller == nil {
+		jsonError(w, "RSS poller not initialized", http.StatusBadRequest)
+		return
+	}
+
+	match, err := s.Poller.RetryMatch(req.ID, strings.TrimSpace(req.Magnet))
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if match == nil {
+		jsonError(w, "match not found", http.StatusNotFound)
+		return
+	}
+
+	jsonOK(w, match)
+}
+
 // handleRSSPollNow triggers an immediate RSS poll.
 func (s *Server) handleRSSPollNow(w http.ResponseWriter, r *http.Request) {
 	if s.Poller == nil {
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"link-anime/internal/rss"
+)
+
+// maxImportSize bounds uploaded rule sets.
+const maxImportSize = 1 << 20
+
+// handleExportRSSRules downloads all rules as JSON, or YAML with ?format=yaml.
+func (s *Server) handleExportRSSRules(w http.ResponseWriter, r *http.Request) {
+	set, err := rss.ExportRules()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") != "yaml" {
+		w.Header().Set("Content-Disposition", `attachment; filename="link-anime-rules.json"`)
+		jsonOK(w, set)
+		return
+	}
+
+	data, err := yaml.Marshal(set)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Content-Disposition", `attachment; filename="link-anime-rules.yaml"`)
+	w.Write(data)
+}
+
+// handleImportRSSRules imports a JSON or YAML rule set. ?conflict= decides
+// what happens to rules whose name exists: skip (default), overwrite or rename.
+func (s *Server) handleImportRSSRules(w http.ResponseWriter, r *http.Request) {
+	conflict, ok := conflictParam(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxImportSize))
+	if err != nil {
+		jsonError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	var set rss.RuleSet
+	if isYAML(r, data) {
+		err = yaml.Unmarshal(data, &set)
+	} else {
+		err = json.Unmarshal(data, &set)
+	}
+	if err != nil {
+		jsonError(w, "invalid rule set: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := rss.ImportRules(&set, conflict)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	jsonOK(w, result)
+}
+
+// handleImportQbitRSSRules converts qBittorrent's RSS auto-download rules and
+// imports them. With ?preview=true it returns the converted rule set instead.
+func (s *Server) handleImportQbitRSSRules(w http.ResponseWriter, r *http.Request) {
+	if s.Qbit == nil || !s.Qbit.IsConfigured() {
+		jsonError(w, "qBittorrent not configured", http.StatusBadRequest)
+		return
+	}
+
+	conflict, ok := conflictParam(w, r)
+	if !ok {
+		return
+	}
+
+	qrules, err := s.Qbit.ListRSSRules()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	set, convErrs := rss.FromQbitRules(qrules)
+	if isTruthy(r.URL.Query().Get("preview")) {
+		jsonOK(w, map[string]interface{}{
+			"rules":  set.Rules,
+			"errors": append([]string{}, convErrs...),
+		})
+		return
+	}
+
+	result, err := rss.ImportRules(set, conflict)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result.Errors = append(convErrs, result.Errors...)
+	jsonOK(w, result)
+}
+
+func conflictParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	conflict := r.URL.Query().Get("conflict")
+	if conflict == "" {
+		conflict = rss.ConflictSkip
+	}
+	if !rss.ValidConflict(conflict) {
+		jsonError(w, "conflict must be skip, overwrite or rename", http.StatusBadRequest)
+		return "", false
+	}
+	return conflict, true
+}
+
+// isYAML picks the import format from ?format=, the Content-Type, or the
+// body itself (JSON rule sets start with "{").
+func isYAML(r *http.Request, data []byte) bool {
+	switch r.URL.Query().Get("format") {
+	case "yaml":
+		return true
+	case "json":
+		return false
+	}
+	if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+		return true
+	}
+	return !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
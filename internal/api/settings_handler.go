@@ -8,8 +8,11 @@ import (
 	"strconv"
 
 	"link-anime/internal/auth"
+	"link-anime/internal/cleanup"
 	"link-anime/internal/database"
+	"link-anime/internal/httpx"
 	"link-anime/internal/models"
+	"link-anime/internal/rss"
 )
 
 func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
@@ -24,12 +27,22 @@ func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
 		DownloadDir:  s.getDownloadDir(),
 		MediaDir:     s.getMediaDir(),
 		MoviesDir:    s.getMoviesDir(),
+
+		MinFreeSpaceGB: int(s.MinFreeSpace() >> 30),
+
+		CleanupEnabled:       cleanup.Enabled(),
+		CleanupIntervalHours: cleanup.IntervalHours(),
+
+		RSSPollIntervalMinutes: rss.PollIntervalMinutes(),
+
+		IndexerProxy: settingOr("indexer_proxy", s.Config.IndexerProxy),
 	}
 
-	// Mask password
+	// Mask password and proxy credentials
 	if settings.QbitPass != "" {
 		settings.QbitPass = "********"
 	}
+	settings.IndexerProxy = httpx.MaskProxy(settings.IndexerProxy)
 
 	jsonOK(w, settings)
 }
@@ -40,6 +53,12 @@ func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 		jsonError(w, "invalid request", http.StatusBadRequest)
 		return
 	}
+	// Keep stored proxy credentials if they come back masked
+	req.IndexerProxy = httpx.UnmaskProxy(req.IndexerProxy, settingOr("indexer_proxy", s.Config.IndexerProxy))
+	if err := httpx.ValidateProxy(req.IndexerProxy); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	pairs := map[string]string{
 		"qbit_url":      req.QbitURL,
@@ -51,6 +70,15 @@ func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 		"download_dir":  req.DownloadDir,
 		"media_dir":     req.MediaDir,
 		"movies_dir":    req.MoviesDir,
+
+		"min_free_space_gb": strconv.Itoa(req.MinFreeSpaceGB),
+
+		"cleanup_enabled":        strconv.FormatBool(req.CleanupEnabled),
+		"cleanup_interval_hours": strconv.Itoa(req.CleanupIntervalHours),
+
+		"rss_poll_interval_minutes": strconv.Itoa(req.RSSPollIntervalMinutes),
+
+		"indexer_proxy": req.IndexerProxy,
 	}
 
 	// Only update qbit password if it's not the masked value
@@ -122,6 +150,10 @@ func (s *Server) ReinitClients() {
 
 	notifyURL := settingOr("notify_url", s.Config.NotifyURL)
 	s.Notifier = newNotifier(notifyURL)
+
+	if err := httpx.SetProxy(settingOr("indexer_proxy", s.Config.IndexerProxy)); err != nil {
+		log.Printf("[settings] indexer proxy not applied: %v", err)
+	}
 }
 
 func settingOr(key, fallback string) string {
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/hex"
 	"net/http"
 	"sync"
@@ -136,6 +137,38 @@ func ClearSessionCookie(w http.ResponseWriter) {
 	})
 }
 
+// CalendarToken returns the token for the calendar feed, creating it on
+// first use. Calendar apps can't log in, so the feed URL carries it instead.
+func CalendarToken() (string, error) {
+	token, err := database.GetSetting("calendar_token")
+	if err != nil || token != "" {
+		return token, err
+	}
+	return RotateCalendarToken()
+}
+
+// RotateCalendarToken replaces the calendar token, invalidating old feed URLs.
+func RotateCalendarToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	if err := database.SetSetting("calendar_token", token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// CheckCalendarToken reports whether token matches the calendar token.
+func CheckCalendarToken(token string) bool {
+	stored, err := database.GetSetting("calendar_token")
+	if err != nil || stored == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(token)) == 1
+}
+
 // CleanupExpiredSessions removes expired sessions periodically.
 func CleanupExpiredSessions() {
 	ticker := time.NewTicker(1 * time.Hour)
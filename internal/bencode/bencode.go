@@ -0,0 +1,197 @@
+// Package bencode decodes and encodes the BitTorrent bencode format.
+//
+// Decoded values are int64, string, []interface{} and
+// map[string]interface{}. Byte strings are returned as Go strings, so binary
+// fields such as "pieces" survive unchanged.
+package bencode
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// maxDepth bounds nesting so hostile input can't exhaust the stack.
+const maxDepth = 64
+
+// Decode parses a single bencoded value that must fill data.
+func Decode(data []byte) (interface{}, error) {
+	d := &decoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(data) {
+		return nil, fmt.Errorf("bencode: trailing data at offset %d", d.pos)
+	}
+	return v, nil
+}
+
+// RawField returns the exact bytes of key's value in the top-level
+// dictionary, e.g. "info" for computing a torrent's info hash.
+func RawField(data []byte, key string) ([]byte, error) {
+	d := &decoder{data: data}
+	if d.peek() != 'd' {
+		return nil, fmt.Errorf("bencode: top-level value is not a dictionary")
+	}
+	d.pos++
+	for d.peek() != 'e' {
+		if d.pos >= len(data) {
+			return nil, fmt.Errorf("bencode: unterminated dictionary")
+		}
+		k, err := d.str()
+		if err != nil {
+			return nil, err
+		}
+		start := d.pos
+		if _, err := d.value(1); err != nil {
+			return nil, err
+		}
+		if k == key {
+			return data[start:d.pos], nil
+		}
+	}
+	return nil, fmt.Errorf("bencode: key %q not found", key)
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) peek() byte {
+	if d.pos >= len(d.data) {
+		return 0
+	}
+	return d.data[d.pos]
+}
+
+func (d *decoder) value(depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("bencode: nesting too deep")
+	}
+	switch c := d.peek(); {
+	case c == 'i':
+		return d.integer()
+	case c >= '0' && c <= '9':
+		return d.str()
+	case c == 'l':
+		d.pos++
+		list := []interface{}{}
+		for d.peek() != 'e' {
+			if d.pos >= len(d.data) {
+				return nil, fmt.Errorf("bencode: unterminated list")
+			}
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		d.pos++
+		return list, nil
+	case c == 'd':
+		d.pos++
+		dict := map[string]interface{}{}
+		for d.peek() != 'e' {
+			if d.pos >= len(d.data) {
+				return nil, fmt.Errorf("bencode: unterminated dictionary")
+			}
+			k, err := d.str()
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			dict[k] = v
+		}
+		d.pos++
+		return dict, nil
+	case c == 0:
+		return nil, fmt.Errorf("bencode: unexpected end of data")
+	default:
+		return nil, fmt.Errorf("bencode: unexpected %q at offset %d", c, d.pos)
+	}
+}
+
+func (d *decoder) integer() (int64, error) {
+	end := bytes.IndexByte(d.data[d.pos:], 'e')
+	if end < 0 {
+		return 0, fmt.Errorf("bencode: unterminated integer at offset %d", d.pos)
+	}
+	n, err := strconv.ParseInt(string(d.data[d.pos+1:d.pos+end]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bencode: bad integer at offset %d", d.pos)
+	}
+	d.pos += end + 1
+	return n, nil
+}
+
+func (d *decoder) str() (string, error) {
+	colon := bytes.IndexByte(d.data[d.pos:], ':')
+	if colon < 0 {
+		return "", fmt.Errorf("bencode: bad string at offset %d", d.pos)
+	}
+	n, err := strconv.Atoi(string(d.data[d.pos : d.pos+colon]))
+	if err != nil || n < 0 {
+		return "", fmt.Errorf("bencode: bad string length at offset %d", d.pos)
+	}
+	start := d.pos + colon + 1
+	if n > len(d.data)-start {
+		return "", fmt.Errorf("bencode: string at offset %d runs past end of data", d.pos)
+	}
+	d.pos = start + n
+	return string(d.data[start:d.pos]), nil
+}
+
+// Encode bencodes v, which may hold integers, strings, []byte, lists and
+// string-keyed maps. Dictionary keys are written sorted, as the format requires.
+func Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encode(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encode(buf *bytes.Buffer, v interface{}) error {
+	switch t := v.(type) {
+	case int:
+		fmt.Fprintf(buf, "i%de", t)
+	case int64:
+		fmt.Fprintf(buf, "i%de", t)
+	case string:
+		fmt.Fprintf(buf, "%d:%s", len(t), t)
+	case []byte:
+		fmt.Fprintf(buf, "%d:", len(t))
+		buf.Write(t)
+	case []interface{}:
+		buf.WriteByte('l')
+		for _, item := range t {
+			if err := encode(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('d')
+		for _, k := range keys {
+			fmt.Fprintf(buf, "%d:%s", len(k), k)
+			if err := encode(buf, t[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	default:
+		return fmt.Errorf("bencode: cannot encode %T", v)
+	}
+	return nil
+}
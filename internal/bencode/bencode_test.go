@@ -0,0 +1,67 @@
+package bencode
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want interface{}
+	}{
+		{"i42e", int64(42)},
+		{"i-7e", int64(-7)},
+		{"4:spam", "spam"},
+		{"0:", ""},
+		{"l4:spami1ee", []interface{}{"spam", int64(1)}},
+		{"d3:bar4:spam3:fooi42ee", map[string]interface{}{"bar": "spam", "foo": int64(42)}},
+	}
+	for _, tt := range tests {
+		got, err := Decode([]byte(tt.in))
+		if err != nil {
+			t.Errorf("Decode(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Decode(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "i42", "5:spam", "l4:spam", "d3:fooe", "x", "i1ei2e", "-1:"} {
+		if _, err := Decode([]byte(bad)); err == nil {
+			t.Errorf("Decode(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	v := map[string]interface{}{
+		"z":    int64(1),
+		"a":    []interface{}{"x", int64(-2)},
+		"info": map[string]interface{}{"name": "show", "length": int64(10)},
+	}
+	data, err := Encode(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "d1:al1:xi-2ee4:infod6:lengthi10e4:name4:showe1:zi1ee"; string(data) != want {
+		t.Errorf("Encode = %s, want %s", data, want)
+	}
+
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, v) {
+		t.Errorf("round trip = %#v", got)
+	}
+
+	raw, err := RawField(data, "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != "d6:lengthi10e4:name4:showe" {
+		t.Errorf("RawField = %s", raw)
+	}
+}
@@ -0,0 +1,301 @@
+package cleanup
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"link-anime/internal/database"
+	"link-anime/internal/models"
+	"link-anime/internal/notify"
+	"link-anime/internal/qbit"
+	"link-anime/internal/scanner"
+)
+
+// checkEvery is how often the scheduler looks for a due run.
+const checkEvery = 10 * time.Minute
+
+// DirsGetter returns the current download, media and movies directories.
+type DirsGetter func() (download, media, movies string)
+
+// Cleaner removes completed torrents whose video files are all hardlinked
+// into the library and that have met their category's seeding policy.
+// Because the library holds its own hardlinks, deleting the torrent with
+// its files only removes the download-side copy.
+type Cleaner struct {
+	qbitGetter func() *qbit.Client
+	notifier   func() *notify.Notifier
+	dirs       DirsGetter
+
+	mu      sync.Mutex // serializes runs
+	lastRun time.Time  // last run that got as far as evaluating torrents
+
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+// NewCleaner creates a new cleaner. Getters are functions so they pick up
+// reinitClients() and settings changes.
+func NewCleaner(qbitGetter func() *qbit.Client, notifier func() *notify.Notifier, dirs DirsGetter) *Cleaner {
+	return &Cleaner{
+		qbitGetter: qbitGetter,
+		notifier:   notifier,
+		dirs:       dirs,
+		stopCh:     make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start begins the schedule loop in a goroutine. Runs only happen while
+// cleanup is enabled in settings, at most once per configured interval.
+func (c *Cleaner) Start() {
+	go c.run()
+}
+
+// Stop signals the scheduler to shut down and waits for it.
+func (c *Cleaner) Stop() {
+	close(c.stopCh)
+	<-c.done
+}
+
+// run checks every tick whether a scheduled run is due. The first check comes
+// one tick after startup, so nothing is deleted the moment the server starts,
+// but restarts don't postpone cleanup by a whole interval.
+func (c *Cleaner) run() {
+	defer close(c.done)
+	ticker := time.NewTicker(checkEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			if !Enabled() {
+				continue
+			}
+			c.mu.Lock()
+			due := time.Since(c.lastRun) >= Interval()
+			c.mu.Unlock()
+			if !due {
+				continue
+			}
+			if _, err := c.Run(); err != nil {
+				log.Printf("[cleanup] scheduled run failed: %v", err)
+			}
+		}
+	}
+}
+
+// Preview evaluates every completed torrent without removing anything.
+func (c *Cleaner) Preview() ([]models.CleanupCandidate, error) {
+	client := c.qbitGetter()
+	if client == nil || !client.IsConfigured() {
+		return nil, fmt.Errorf("qBittorrent not configured")
+	}
+
+	policies, err := LoadPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	torrents, err := client.ListTorrents("")
+	if err != nil {
+		return nil, err
+	}
+
+	downloadDir, mediaDir, moviesDir := c.dirs()
+	idx := scanner.BuildLibraryIndex(mediaDir, moviesDir)
+
+	var candidates []models.CleanupCandidate
+	for _, t := range torrents {
+		if t.Progress < 1.0 {
+			continue
+		}
+
+		cand := models.CleanupCandidate{Torrent: t}
+		files, err := client.ListFiles(t.Hash)
+		if err != nil {
+			cand.Reason = "could not list files: " + err.Error()
+			candidates = append(candidates, cand)
+			continue
+		}
+
+		for _, f := range files {
+			if !scanner.IsVideo(f.Name) {
+				continue
+			}
+			cand.VideoFiles++
+			if path := resolveFile(t.SavePath, downloadDir, f.Name); path != "" && idx.IsLinked(path) {
+				cand.LinkedFiles++
+			}
+		}
+
+		cand.Reason = checkPolicy(t, cand, policies)
+		cand.Eligible = cand.Reason == ""
+		candidates = append(candidates, cand)
+	}
+
+	return candidates, nil
+}
+
+// Run removes every eligible torrent along with its download-side files
+// and sends a notification summary.
+func (c *Cleaner) Run() (*models.CleanupResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A failed preview (e.g. qBittorrent down) is retried on the next check
+	candidates, err := c.Preview()
+	if err != nil {
+		return nil, err
+	}
+	c.lastRun = time.Now()
+
+	client := c.qbitGetter()
+	result := &models.CleanupResult{Removed: []string{}, Failed: []string{}}
+	for _, cand := range candidates {
+		if !cand.Eligible {
+			continue
+		}
+		if err := client.DeleteTorrent(cand.Torrent.Hash, true); err != nil {
+			log.Printf("[cleanup] failed to remove %s: %v", cand.Torrent.Name, err)
+			result.Failed = append(result.Failed, cand.Torrent.Name)
+			continue
+		}
+		log.Printf("[cleanup] removed %s (ratio %.2f)", cand.Torrent.Name, cand.Torrent.Ratio)
+		result.Removed = append(result.Removed, cand.Torrent.Name)
+		result.Size += cand.Torrent.Size
+	}
+
+	if n := c.notifier(); n != nil && (len(result.Removed) > 0 || len(result.Failed) > 0) {
+		color := "green"
+		if len(result.Failed) > 0 {
+			color = "red"
+		}
+		n.Send("Torrent Cleanup", fmt.Sprintf("Removed %d seeded torrents already in the library", len(result.Removed)), []notify.Field{
+			{Name: "Removed", Value: strconv.Itoa(len(result.Removed))},
+			{Name: "Failed", Value: strconv.Itoa(len(result.Failed))},
+			{Name: "Size", Value: notify.FormatSize(result.Size)},
+		}, color)
+	}
+
+	return result, nil
+}
+
+// --- Settings ---
+
+// Enabled reports whether scheduled cleanup is turned on.
+func Enabled() bool {
+	v, _ := database.GetSetting("cleanup_enabled")
+	return v == "true"
+}
+
+// Interval returns the scheduled cleanup interval (default 24h).
+func Interval() time.Duration {
+	return time.Duration(IntervalHours()) * time.Hour
+}
+
+// IntervalHours returns the scheduled cleanup interval in hours (default 24).
+func IntervalHours() int {
+	if v, err := database.GetSetting("cleanup_interval_hours"); err == nil && v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 24
+}
+
+// LoadPolicies returns the per-category seeding policies.
+func LoadPolicies() ([]models.CleanupPolicy, error) {
+	v, err := database.GetSetting("cleanup_policies")
+	if err != nil {
+		return nil, err
+	}
+	if v == "" {
+		return []models.CleanupPolicy{}, nil
+	}
+	var policies []models.CleanupPolicy
+	if err := json.Unmarshal([]byte(v), &policies); err != nil {
+		return nil, fmt.Errorf("decode cleanup policies: %w", err)
+	}
+	return policies, nil
+}
+
+// SavePolicies stores the per-category seeding policies.
+func SavePolicies(policies []models.CleanupPolicy) error {
+	seen := make(map[string]bool)
+	for _, p := range policies {
+		if seen[p.Category] {
+			return fmt.Errorf("duplicate policy for category %q", p.Category)
+		}
+		seen[p.Category] = true
+		if p.MinRatio < 0 || p.MinSeedMinutes < 0 {
+			return fmt.Errorf("policy for category %q has negative limits", p.Category)
+		}
+	}
+
+	data, err := json.Marshal(policies)
+	if err != nil {
+		return err
+	}
+	return database.SetSetting("cleanup_policies", string(data))
+}
+
+// --- helpers ---
+
+// checkPolicy returns why a candidate can't be removed, or "" if it can.
+func checkPolicy(t models.TorrentStatus, cand models.CleanupCandidate, policies []models.CleanupPolicy) string {
+	if cand.VideoFiles == 0 {
+		return "no video files"
+	}
+	if cand.LinkedFiles < cand.VideoFiles {
+		return fmt.Sprintf("only %d of %d video files are in the library", cand.LinkedFiles, cand.VideoFiles)
+	}
+
+	policy := findPolicy(t.Category, policies)
+	if policy == nil {
+		return fmt.Sprintf("no cleanup policy for category %q", t.Category)
+	}
+	if t.Ratio < policy.MinRatio {
+		return fmt.Sprintf("ratio %.2f below %.2f", t.Ratio, policy.MinRatio)
+	}
+	if seeded := t.SeedingTime / 60; seeded < int64(policy.MinSeedMinutes) {
+		return fmt.Sprintf("seeded %d of %d minutes", seeded, policy.MinSeedMinutes)
+	}
+	return ""
+}
+
+// findPolicy returns the policy for category, falling back to the default (empty category).
+func findPolicy(category string, policies []models.CleanupPolicy) *models.CleanupPolicy {
+	var fallback *models.CleanupPolicy
+	for i := range policies {
+		if policies[i].Category == category {
+			return &policies[i]
+		}
+		if policies[i].Category == "" {
+			fallback = &policies[i]
+		}
+	}
+	return fallback
+}
+
+// resolveFile maps a torrent file to a local path. qBittorrent may run in a
+// different container, so its save path is tried first, then the download dir.
+func resolveFile(savePath, downloadDir, name string) string {
+	for _, dir := range []string{savePath, downloadDir} {
+		if dir == "" {
+			continue
+		}
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
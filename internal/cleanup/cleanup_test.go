@@ -0,0 +1,186 @@
+package cleanup
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"link-anime/internal/database"
+	"link-anime/internal/models"
+	"link-anime/internal/notify"
+	"link-anime/internal/qbit"
+)
+
+func TestFindPolicy(t *testing.T) {
+	policies := []models.CleanupPolicy{
+		{Category: "anime", MinRatio: 2},
+		{Category: "", MinRatio: 1},
+	}
+	tests := []struct {
+		category string
+		want     float64
+	}{
+		{"anime", 2},
+		{"movies", 1}, // falls back to the default
+		{"", 1},
+	}
+	for _, tt := range tests {
+		p := findPolicy(tt.category, policies)
+		if p == nil || p.MinRatio != tt.want {
+			t.Errorf("findPolicy(%q) = %+v, want ratio %v", tt.category, p, tt.want)
+		}
+	}
+	if p := findPolicy("movies", policies[:1]); p != nil {
+		t.Errorf("without a default, findPolicy = %+v, want nil", p)
+	}
+}
+
+func TestCheckPolicy(t *testing.T) {
+	policies := []models.CleanupPolicy{{Category: "anime", MinRatio: 1, MinSeedMinutes: 60}}
+	seeded := models.TorrentStatus{Category: "anime", Ratio: 1.5, SeedingTime: 2 * 3600}
+	full := models.CleanupCandidate{VideoFiles: 2, LinkedFiles: 2}
+
+	tests := []struct {
+		name    string
+		torrent models.TorrentStatus
+		cand    models.CleanupCandidate
+		reason  string // substring; "" means eligible
+	}{
+		{"eligible", seeded, full, ""},
+		{"no videos", seeded, models.CleanupCandidate{}, "no video files"},
+		{"partly linked", seeded, models.CleanupCandidate{VideoFiles: 2, LinkedFiles: 1}, "only 1 of 2"},
+		{"no policy", models.TorrentStatus{Category: "tv", Ratio: 5, SeedingTime: 99999}, full, "no cleanup policy"},
+		{"low ratio", models.TorrentStatus{Category: "anime", Ratio: 0.5, SeedingTime: 7200}, full, "ratio 0.50 below 1.00"},
+		{"short seeding", models.TorrentStatus{Category: "anime", Ratio: 2, SeedingTime: 30 * 60}, full, "seeded 30 of 60 minutes"},
+	}
+	for _, tt := range tests {
+		got := checkPolicy(tt.torrent, tt.cand, policies)
+		if (tt.reason == "") != (got == "") || !strings.Contains(got, tt.reason) {
+			t.Errorf("%s: checkPolicy = %q, want %q", tt.name, got, tt.reason)
+		}
+	}
+}
+
+func TestResolveFile(t *testing.T) {
+	root := t.TempDir()
+	save, download := filepath.Join(root, "save"), filepath.Join(root, "downloads")
+	write(t, filepath.Join(save, "A", "a.mkv"))
+	write(t, filepath.Join(download, "B", "b.mkv"))
+
+	tests := []struct {
+		savePath, name, want string
+	}{
+		{save, "A/a.mkv", filepath.Join(save, "A", "a.mkv")},
+		{"/elsewhere/in/qbit/container", "B/b.mkv", filepath.Join(download, "B", "b.mkv")},
+		{"", "B/b.mkv", filepath.Join(download, "B", "b.mkv")},
+		{save, "C/missing.mkv", ""},
+	}
+	for _, tt := range tests {
+		if got := resolveFile(tt.savePath, download, tt.name); got != tt.want {
+			t.Errorf("resolveFile(%q, %q) = %q, want %q", tt.savePath, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPreviewAndRun(t *testing.T) {
+	if err := database.Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if err := SavePolicies([]models.CleanupPolicy{{Category: "", MinRatio: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	download, media := filepath.Join(root, "downloads"), filepath.Join(root, "media")
+	write(t, filepath.Join(download, "Linked", "Show - 01.mkv"))
+	write(t, filepath.Join(download, "Unlinked", "Show - 02.mkv"))
+	libFile := filepath.Join(media, "Show", "Season 1", "Show - 01.mkv")
+	os.MkdirAll(filepath.Dir(libFile), 0755)
+	if err := os.Link(filepath.Join(download, "Linked", "Show - 01.mkv"), libFile); err != nil {
+		t.Fatal(err)
+	}
+
+	torrents := []map[string]interface{}{
+		{"name": "Linked", "hash": "aaa", "progress": 1.0, "ratio": 2.0, "save_path": download},
+		{"name": "Unlinked", "hash": "bbb", "progress": 1.0, "ratio": 2.0, "save_path": download},
+		{"name": "Downloading", "hash": "ccc", "progress": 0.5, "ratio": 0.0, "save_path": download},
+	}
+	files := map[string][]map[string]interface{}{
+		"aaa": {{"name": "Linked/Show - 01.mkv"}, {"name": "Linked/info.nfo"}},
+		"bbb": {{"name": "Unlinked/Show - 02.mkv"}},
+	}
+	var mu sync.Mutex
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/auth/login":
+			w.Write([]byte("Ok."))
+		case "/api/v2/torrents/info":
+			json.NewEncoder(w).Encode(torrents)
+		case "/api/v2/torrents/files":
+			json.NewEncoder(w).Encode(files[r.URL.Query().Get("hash")])
+		case "/api/v2/torrents/delete":
+			r.ParseForm()
+			mu.Lock()
+			deleted = append(deleted, r.PostForm.Get("hashes")+" deleteFiles="+r.PostForm.Get("deleteFiles"))
+			mu.Unlock()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := qbit.New(srv.URL, "admin", "secret")
+	c := NewCleaner(
+		func() *qbit.Client { return client },
+		func() *notify.Notifier { return nil },
+		func() (string, string, string) { return download, media, "" },
+	)
+
+	cands, err := c.Preview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cands) != 2 {
+		t.Fatalf("candidates = %+v, want the two completed torrents", cands)
+	}
+	byName := map[string]models.CleanupCandidate{}
+	for _, cand := range cands {
+		byName[cand.Torrent.Name] = cand
+	}
+	if cand := byName["Linked"]; !cand.Eligible || cand.VideoFiles != 1 || cand.LinkedFiles != 1 {
+		t.Errorf("Linked = %+v, want eligible with 1 of 1 video linked", cand)
+	}
+	if cand := byName["Unlinked"]; cand.Eligible || cand.LinkedFiles != 0 {
+		t.Errorf("Unlinked = %+v, want not eligible", cand)
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("Preview deleted %v", deleted)
+	}
+
+	result, err := c.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Removed) != 1 || result.Removed[0] != "Linked" {
+		t.Errorf("removed = %v, want [Linked]", result.Removed)
+	}
+	if want := []string{"aaa deleteFiles=true"}; len(deleted) != 1 || deleted[0] != want[0] {
+		t.Errorf("deleted = %v, want %v", deleted, want)
+	}
+}
+
+func write(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -31,6 +31,15 @@ type Config struct {
 
 	// Notifications
 	NotifyURL string
+
+	// Nyaa site or mirror to search and fetch torrents from
+	NyaaURL string
+
+	// HTTP or SOCKS5 proxy for indexer and feed traffic
+	IndexerProxy string
+
+	// Disk space: RSS grabs pause when any root has less free space than this (0 disables)
+	MinFreeSpaceGB int
 }
 
 func Load() *Config {
@@ -54,6 +63,11 @@ func Load() *Config {
 		ShokoAPIKey: envStr("LA_SHOKO_APIKEY", ""),
 
 		NotifyURL: envStr("LA_NOTIFY_URL", ""),
+
+		NyaaURL:      envStr("LA_NYAA_URL", "https://nyaa.si"),
+		IndexerProxy: envStr("LA_INDEXER_PROXY", ""),
+
+		MinFreeSpaceGB: envInt("LA_MIN_FREE_SPACE_GB", 0),
 	}
 }
 
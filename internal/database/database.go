@@ -84,6 +84,19 @@ func migrate() error {
 			matched  DATETIME DEFAULT CURRENT_TIMESTAMP,
 			status   TEXT DEFAULT 'downloaded'
 		)`,
+		`CREATE TABLE IF NOT EXISTS download_states (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			path       TEXT UNIQUE NOT NULL,
+			inode      INTEGER NOT NULL DEFAULT 0,
+			state      TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_download_states_inode ON download_states(inode)`,
+		`CREATE TABLE IF NOT EXISTS ignore_patterns (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			pattern    TEXT UNIQUE NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
 	}
 
 	for _, m := range migrations {
@@ -92,6 +105,187 @@ func migrate() error {
 		}
 	}
 
+	return upgrade()
+}
+
+// upgrades change existing tables. Each runs once, in order, tracked by
+// SQLite's user_version pragma. Append new steps; never edit old ones.
+var upgrades = [][]string{
+	// 1: rss_matches dedupe per rule on info hash instead of a global title hash
+	{
+		`CREATE TABLE rss_matches_new (
+			id        INTEGER PRIMARY KEY AUTOINCREMENT,
+			rule_id   INTEGER REFERENCES rss_rules(id) ON DELETE CASCADE,
+			title     TEXT NOT NULL,
+			hash      TEXT NOT NULL,
+			info_hash TEXT NOT NULL DEFAULT '',
+			matched   DATETIME DEFAULT CURRENT_TIMESTAMP,
+			status    TEXT DEFAULT 'downloaded',
+			UNIQUE(rule_id, hash)
+		)`,
+		`INSERT OR IGNORE INTO rss_matches_new (id, rule_id, title, hash, matched, status)
+		 SELECT id, rule_id, title, hash, matched, status FROM rss_matches`,
+		`DROP TABLE rss_matches`,
+		`ALTER TABLE rss_matches_new RENAME TO rss_matches`,
+		`CREATE INDEX IF NOT EXISTS idx_rss_matches_info_hash ON rss_matches(info_hash)`,
+	},
+	// 2: regex, release group, size, codec and source filters on rss_rules
+	{
+		`ALTER TABLE rss_rules ADD COLUMN include_pattern TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_rules ADD COLUMN exclude_pattern TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_rules ADD COLUMN groups TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_rules ADD COLUMN blocked_groups TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_rules ADD COLUMN min_size INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE rss_rules ADD COLUMN max_size INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE rss_rules ADD COLUMN codecs TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_rules ADD COLUMN sources TEXT NOT NULL DEFAULT ''`,
+	},
+	// 3: per-rule feed sources; existing rules keep the trusted Nyaa search
+	{
+		`ALTER TABLE rss_rules ADD COLUMN source_type TEXT NOT NULL DEFAULT 'nyaa'`,
+		`ALTER TABLE rss_rules ADD COLUMN nyaa_category TEXT NOT NULL DEFAULT '1_2'`,
+		`ALTER TABLE rss_rules ADD COLUMN nyaa_filter TEXT NOT NULL DEFAULT 'trusted'`,
+		`ALTER TABLE rss_rules ADD COLUMN feed_user TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_rules ADD COLUMN feed_url TEXT NOT NULL DEFAULT ''`,
+	},
+	// 4: episode-aware dedupe, upgrade policy and auto-link
+	{
+		`ALTER TABLE rss_rules ADD COLUMN upgrade_policy TEXT NOT NULL DEFAULT 'none'`,
+		`ALTER TABLE rss_rules ADD COLUMN auto_link BOOLEAN NOT NULL DEFAULT 0`,
+		`ALTER TABLE rss_matches ADD COLUMN season INTEGER`,
+		`ALTER TABLE rss_matches ADD COLUMN episode INTEGER`,
+		`ALTER TABLE rss_matches ADD COLUMN resolution TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_matches ADD COLUMN release_group TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_matches ADD COLUMN revision INTEGER NOT NULL DEFAULT 1`,
+		`ALTER TABLE rss_matches ADD COLUMN score INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE rss_matches ADD COLUMN upgrade_of INTEGER`,
+		`CREATE INDEX IF NOT EXISTS idx_rss_matches_episode ON rss_matches(rule_id, season, episode)`,
+	},
+	// 5: quality profiles, and logged decisions for delayed grabs
+	{
+		`CREATE TABLE IF NOT EXISTS quality_profiles (
+			id            INTEGER PRIMARY KEY AUTOINCREMENT,
+			name          TEXT UNIQUE NOT NULL,
+			resolutions   TEXT NOT NULL DEFAULT '[]',
+			sources       TEXT NOT NULL DEFAULT '[]',
+			groups        TEXT NOT NULL DEFAULT '[]',
+			codecs        TEXT NOT NULL DEFAULT '[]',
+			min_score     INTEGER NOT NULL DEFAULT 0,
+			delay_minutes INTEGER NOT NULL DEFAULT 0,
+			created_at    DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`ALTER TABLE rss_rules ADD COLUMN profile_id INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE rss_matches ADD COLUMN reason TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_matches ADD COLUMN magnet TEXT NOT NULL DEFAULT ''`,
+	},
+	// 6: rule completion and expiry
+	{
+		`ALTER TABLE rss_rules ADD COLUMN expected_episodes INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE rss_rules ADD COLUMN end_date DATETIME`,
+		`ALTER TABLE rss_rules ADD COLUMN completed_at DATETIME`,
+		`ALTER TABLE rss_rules ADD COLUMN completed_reason TEXT NOT NULL DEFAULT ''`,
+	},
+	// 7: per-rule poll schedule and fetch backoff
+	{
+		`ALTER TABLE rss_rules ADD COLUMN poll_interval_minutes INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE rss_rules ADD COLUMN next_check DATETIME`,
+		`ALTER TABLE rss_rules ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0`,
+	},
+	// 8: rule health
+	{
+		`ALTER TABLE rss_rules ADD COLUMN last_error TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_rules ADD COLUMN last_success DATETIME`,
+		`ALTER TABLE rss_rules ADD COLUMN last_match DATETIME`,
+		`UPDATE rss_rules SET last_success = last_check`,
+		`UPDATE rss_rules SET last_match = (
+			SELECT matched FROM rss_matches
+			WHERE rule_id = rss_rules.id AND status IN ('downloaded', 'pending', 'linked')
+			ORDER BY matched DESC LIMIT 1
+		)`,
+	},
+	// 9: qBittorrent add options per rule
+	{
+		`ALTER TABLE rss_rules ADD COLUMN category TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_rules ADD COLUMN tags TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_rules ADD COLUMN save_path TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_rules ADD COLUMN add_paused BOOLEAN NOT NULL DEFAULT 0`,
+		`ALTER TABLE rss_rules ADD COLUMN sequential BOOLEAN NOT NULL DEFAULT 0`,
+		`ALTER TABLE rss_rules ADD COLUMN first_last_piece BOOLEAN NOT NULL DEFAULT 0`,
+	},
+	// 10: retry queue for pending and failed grabs
+	{
+		`ALTER TABLE rss_matches ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE rss_matches ADD COLUMN next_retry DATETIME`,
+	},
+	// 11: air schedule and missed-episode alerts
+	{
+		`ALTER TABLE rss_rules ADD COLUMN air_day INTEGER`,
+		`ALTER TABLE rss_rules ADD COLUMN air_time TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE rss_rules ADD COLUMN missed_alert_at DATETIME`,
+	},
+	// 12: configurable indexers, and rules searching them
+	{
+		`CREATE TABLE IF NOT EXISTS indexers (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			name       TEXT UNIQUE NOT NULL,
+			type       TEXT NOT NULL,
+			url        TEXT NOT NULL DEFAULT '',
+			api_key    TEXT NOT NULL DEFAULT '',
+			categories TEXT NOT NULL DEFAULT '[]',
+			filter     TEXT NOT NULL DEFAULT '',
+			priority   INTEGER NOT NULL DEFAULT 0,
+			enabled    BOOLEAN NOT NULL DEFAULT 1,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`ALTER TABLE rss_rules ADD COLUMN indexers TEXT NOT NULL DEFAULT ''`,
+	},
+	// 13: library files each auto-linked match created, so upgrades only
+	// remove what the replaced release linked
+	{
+		`CREATE TABLE IF NOT EXISTS rss_match_files (
+			match_id INTEGER NOT NULL REFERENCES rss_matches(id) ON DELETE CASCADE,
+			path     TEXT NOT NULL,
+			dev      INTEGER NOT NULL,
+			ino      INTEGER NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_rss_match_files_match ON rss_match_files(match_id)`,
+	},
+	// 14: download states keyed on device and inode, since inode numbers
+	// are only unique per filesystem
+	{
+		`ALTER TABLE download_states ADD COLUMN device INTEGER NOT NULL DEFAULT 0`,
+		`CREATE INDEX IF NOT EXISTS idx_download_states_file ON download_states(device, inode)`,
+	},
+}
+
+func upgrade() error {
+	var version int
+	if err := DB.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for i := version; i < len(upgrades); i++ {
+		tx, err := DB.Begin()
+		if err != nil {
+			return err
+		}
+		for _, stmt := range upgrades[i] {
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("upgrade %d failed: %w\nSQL: %s", i+1, err, stmt)
+			}
+		}
+		// PRAGMA doesn't accept bound parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("set schema version: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("upgrade %d commit: %w", i+1, err)
+		}
+		log.Printf("Database upgraded to schema version %d", i+1)
+	}
+
 	return nil
 }
 
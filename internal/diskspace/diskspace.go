@@ -0,0 +1,207 @@
+package diskspace
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"link-anime/internal/models"
+	"link-anime/internal/notify"
+)
+
+// Root is a named directory whose filesystem is reported on.
+type Root struct {
+	Name string // "download", "media", "movies"
+	Path string
+}
+
+// Stat returns space and device information for the filesystem holding root.
+func Stat(root Root) models.DiskInfo {
+	info := models.DiskInfo{Name: root.Name, Path: root.Path}
+
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(root.Path, &fs); err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.Total = fs.Blocks * uint64(fs.Bsize)
+	info.Free = fs.Bavail * uint64(fs.Bsize)
+	info.Used = info.Total - fs.Bfree*uint64(fs.Bsize)
+
+	fi, err := os.Stat(root.Path)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		info.Device = uint64(st.Dev)
+	}
+
+	return info
+}
+
+// Report stats every configured root and records which roots share a
+// filesystem. Hardlinks only work between roots on the same device. Roots
+// with no path, such as an unused movies dir, are left out.
+func Report(roots []Root) *models.DiskReport {
+	report := &models.DiskReport{}
+	for _, r := range roots {
+		if r.Path == "" {
+			continue
+		}
+		report.Disks = append(report.Disks, Stat(r))
+	}
+
+	for i := range report.Disks {
+		a := &report.Disks[i]
+		if a.Error != "" {
+			continue
+		}
+		for _, b := range report.Disks {
+			if b.Name != a.Name && b.Error == "" && b.Device == a.Device {
+				a.SharedWith = append(a.SharedWith, b.Name)
+			}
+		}
+	}
+
+	// Linking works if every library root is on the download root's device
+	var download *models.DiskInfo
+	for i := range report.Disks {
+		if report.Disks[i].Name == "download" {
+			download = &report.Disks[i]
+		}
+	}
+	report.Hardlinkable = download != nil && download.Error == ""
+	for _, d := range report.Disks {
+		if d.Error != "" || (download != nil && d.Device != download.Device) {
+			report.Hardlinkable = false
+		}
+	}
+
+	return report
+}
+
+// Watcher periodically checks free space against a threshold and sends a
+// notification when any root drops below it (and again when it recovers).
+type Watcher struct {
+	roots     func() []Root
+	threshold func() uint64 // minimum free bytes; 0 disables the check
+	notifier  func() *notify.Notifier
+	interval  time.Duration
+
+	mu  sync.Mutex
+	low bool
+
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+// NewWatcher creates a new disk space watcher.
+// All arguments are functions so they pick up settings changes at runtime.
+func NewWatcher(
+	roots func() []Root,
+	threshold func() uint64,
+	notifier func() *notify.Notifier,
+	interval time.Duration,
+) *Watcher {
+	return &Watcher{
+		roots:     roots,
+		threshold: threshold,
+		notifier:  notifier,
+		interval:  interval,
+		stopCh:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start begins the watch loop in a goroutine.
+func (w *Watcher) Start() {
+	go w.run()
+}
+
+// Stop signals the watcher to shut down and waits for it.
+func (w *Watcher) Stop() {
+	close(w.stopCh)
+	<-w.done
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.Check()
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// HasSpace checks the disks now and reports whether every root is above the threshold.
+func (w *Watcher) HasSpace() bool {
+	return !w.Check()
+}
+
+// Check stats all roots and returns true if any is below the threshold.
+// A notification is sent whenever the low-space state changes.
+func (w *Watcher) Check() bool {
+	threshold := w.threshold()
+
+	var lowDisks []models.DiskInfo
+	if threshold > 0 {
+		for _, r := range w.roots() {
+			if r.Path == "" {
+				continue
+			}
+			info := Stat(r)
+			if info.Error == "" && info.Free < threshold {
+				lowDisks = append(lowDisks, info)
+			}
+		}
+	}
+	low := len(lowDisks) > 0
+
+	w.mu.Lock()
+	changed := low != w.low
+	w.low = low
+	w.mu.Unlock()
+
+	if !changed {
+		return low
+	}
+
+	n := w.notifier()
+	if low {
+		for _, d := range lowDisks {
+			log.Printf("[disk] low space on %s (%s): %s free", d.Name, d.Path, notify.FormatSize(int64(d.Free)))
+			if n != nil {
+				n.Send("Low Disk Space", fmt.Sprintf("%s is below the free space threshold; RSS grabs are paused", d.Path), []notify.Field{
+					{Name: "Free", Value: notify.FormatSize(int64(d.Free))},
+					{Name: "Threshold", Value: notify.FormatSize(int64(threshold))},
+				}, "red")
+			}
+		}
+	} else {
+		log.Printf("[disk] free space recovered above threshold")
+		if n != nil {
+			n.Send("Disk Space Recovered", "Free space is above the threshold again; RSS grabs resumed", nil, "green")
+		}
+	}
+
+	return low
+}
+
+// IsLow returns the result of the most recent check without touching the disks.
+func (w *Watcher) IsLow() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.low
+}
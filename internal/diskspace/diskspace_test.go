@@ -0,0 +1,26 @@
+package diskspace
+
+import "testing"
+
+func TestReportSkipsUnsetRoots(t *testing.T) {
+	dir := t.TempDir()
+	report := Report([]Root{
+		{Name: "download", Path: dir},
+		{Name: "media", Path: dir},
+		{Name: "movies", Path: ""},
+	})
+	if len(report.Disks) != 2 {
+		t.Fatalf("disks = %+v, want download and media only", report.Disks)
+	}
+	if !report.Hardlinkable {
+		t.Error("roots on one filesystem should be hardlinkable")
+	}
+
+	report = Report([]Root{
+		{Name: "download", Path: dir},
+		{Name: "media", Path: dir + "/missing"},
+	})
+	if report.Hardlinkable {
+		t.Error("a configured root that can't be read should not be hardlinkable")
+	}
+}
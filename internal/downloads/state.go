@@ -0,0 +1,315 @@
+package downloads
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"link-anime/internal/database"
+	"link-anime/internal/models"
+	"link-anime/internal/scanner"
+)
+
+// Item states. An item with no stored state is active.
+const (
+	StateIgnored         = "ignored"
+	StateArchived        = "archived"
+	StateLinkedElsewhere = "linked_elsewhere"
+)
+
+// ValidState reports whether s is a state that can be stored.
+func ValidState(s string) bool {
+	switch s {
+	case StateIgnored, StateArchived, StateLinkedElsewhere:
+		return true
+	}
+	return false
+}
+
+// fileKey identifies an item's data; inode numbers are only unique per device.
+type fileKey struct {
+	dev, ino uint64
+}
+
+// stateIndex looks up stored states by path, falling back to the file
+// identity so renamed items keep their state.
+type stateIndex struct {
+	byPath map[string]string
+	byFile map[fileKey]string
+}
+
+// indexStates builds a stateIndex. Only states whose path no longer exists
+// are reachable by file identity: while the path is still there, any other
+// item sharing its inode is a different item.
+func indexStates(states []models.DownloadState, exists func(string) bool) stateIndex {
+	idx := stateIndex{
+		byPath: make(map[string]string, len(states)),
+		byFile: make(map[fileKey]string),
+	}
+	for _, st := range states {
+		idx.byPath[st.Path] = st.State
+		if st.Device != 0 && st.Inode != 0 && !exists(st.Path) {
+			idx.byFile[fileKey{st.Device, st.Inode}] = st.State
+		}
+	}
+	return idx
+}
+
+func (idx stateIndex) lookup(item *models.DownloadItem) (string, bool) {
+	if st, ok := idx.byPath[item.Path]; ok {
+		return st, true
+	}
+	if item.Inode == 0 {
+		return "", false
+	}
+	st, ok := idx.byFile[fileKey{item.Device, item.Inode}]
+	return st, ok
+}
+
+// staleStates returns the IDs of states whose path is gone and whose file
+// isn't among present, the items currently in the download directory.
+func staleStates(states []models.DownloadState, exists func(string) bool, present map[fileKey]bool) []int64 {
+	var stale []int64
+	for _, st := range states {
+		if exists(st.Path) {
+			continue
+		}
+		if st.Device != 0 && present[fileKey{st.Device, st.Inode}] {
+			continue // renamed
+		}
+		stale = append(stale, st.ID)
+	}
+	return stale
+}
+
+// Filter builds a scanner filter that annotates items with their stored
+// state and keeps only those matching show:
+//   - "" keeps active items (no state and no matching ignore pattern)
+//   - "all" keeps everything
+//   - a state name keeps only items in that state
+//
+// States for items that have left downloadDir are pruned first.
+func Filter(show, downloadDir string) (scanner.DownloadFilter, error) {
+	if show != "" && show != "all" && !ValidState(show) {
+		return nil, fmt.Errorf("unknown state filter: %s", show)
+	}
+
+	if err := Prune(downloadDir); err != nil {
+		return nil, err
+	}
+	states, err := ListStates()
+	if err != nil {
+		return nil, err
+	}
+	patterns, err := ListPatterns()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := indexStates(states, pathExists)
+
+	return func(item *models.DownloadItem) bool {
+		if st, ok := idx.lookup(item); ok {
+			item.State = st
+		} else if matchesAny(item.Name, patterns) {
+			item.State = StateIgnored
+		}
+
+		switch show {
+		case "all":
+			return true
+		case "":
+			return item.State == ""
+		default:
+			return item.State == show
+		}
+	}, nil
+}
+
+// SetState stores state for each path, replacing any existing state.
+func SetState(paths []string, state string) error {
+	if !ValidState(state) {
+		return fmt.Errorf("invalid state: %s", state)
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("set state: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, p := range paths {
+		dev, ino := scanner.FileIdentity(p)
+		_, err := tx.Exec(`
+			INSERT INTO download_states (path, device, inode, state) VALUES (?, ?, ?, ?)
+			ON CONFLICT(path) DO UPDATE SET device = excluded.device, inode = excluded.inode,
+			       state = excluded.state, updated_at = CURRENT_TIMESTAMP
+		`, p, int64(dev), int64(ino), state)
+		if err != nil {
+			return fmt.Errorf("set state %s: %w", p, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ClearState removes the stored state for each path, and for the file at
+// that path if it was stored under an earlier name.
+func ClearState(paths []string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("clear state: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, p := range paths {
+		if _, err := tx.Exec("DELETE FROM download_states WHERE path = ?", p); err != nil {
+			return fmt.Errorf("clear state %s: %w", p, err)
+		}
+		if dev, ino := scanner.FileIdentity(p); ino != 0 {
+			_, err := tx.Exec("DELETE FROM download_states WHERE device = ? AND inode = ?", int64(dev), int64(ino))
+			if err != nil {
+				return fmt.Errorf("clear state %s: %w", p, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListStates returns all stored item states.
+func ListStates() ([]models.DownloadState, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, path, device, inode, state, updated_at FROM download_states ORDER BY updated_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list states: %w", err)
+	}
+	defer rows.Close()
+
+	var states []models.DownloadState
+	for rows.Next() {
+		var st models.DownloadState
+		var device, inode sql.NullInt64
+		if err := rows.Scan(&st.ID, &st.Path, &device, &inode, &st.State, &st.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan state: %w", err)
+		}
+		st.Device = uint64(device.Int64)
+		st.Inode = uint64(inode.Int64)
+		states = append(states, st)
+	}
+
+	return states, nil
+}
+
+// Prune deletes states for items that are gone: their path no longer exists
+// and no item in downloadDir has their file identity. This keeps a new
+// download that reuses a deleted item's inode from inheriting its state.
+func Prune(downloadDir string) error {
+	states, err := ListStates()
+	if err != nil || len(states) == 0 {
+		return err
+	}
+
+	entries, err := os.ReadDir(downloadDir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("prune states: %w", err)
+	}
+	present := make(map[fileKey]bool, len(entries))
+	for _, e := range entries {
+		if dev, ino := scanner.FileIdentity(filepath.Join(downloadDir, e.Name())); ino != 0 {
+			present[fileKey{dev, ino}] = true
+		}
+	}
+
+	// Rows stored before devices were recorded get one while their path is still there
+	for _, st := range states {
+		if st.Device != 0 {
+			continue
+		}
+		if dev, ino := scanner.FileIdentity(st.Path); ino != 0 {
+			_, err := database.DB.Exec("UPDATE download_states SET device = ?, inode = ? WHERE id = ?",
+				int64(dev), int64(ino), st.ID)
+			if err != nil {
+				return fmt.Errorf("prune states: %w", err)
+			}
+		}
+	}
+
+	for _, id := range staleStates(states, pathExists, present) {
+		if _, err := database.DB.Exec("DELETE FROM download_states WHERE id = ?", id); err != nil {
+			return fmt.Errorf("prune state: %w", err)
+		}
+	}
+	return nil
+}
+
+// pathExists reports false only when p is known to be gone.
+func pathExists(p string) bool {
+	_, err := os.Stat(p)
+	return !os.IsNotExist(err)
+}
+
+// --- Ignore patterns ---
+
+// ListPatterns returns all ignore patterns.
+func ListPatterns() ([]models.IgnorePattern, error) {
+	rows, err := database.DB.Query("SELECT id, pattern, created_at FROM ignore_patterns ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("list patterns: %w", err)
+	}
+	defer rows.Close()
+
+	var patterns []models.IgnorePattern
+	for rows.Next() {
+		var p models.IgnorePattern
+		if err := rows.Scan(&p.ID, &p.Pattern, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan pattern: %w", err)
+		}
+		patterns = append(patterns, p)
+	}
+
+	return patterns, nil
+}
+
+// AddPattern stores a new glob ignore pattern (e.g. "*.flac", "*sample*").
+// Brackets are glob character classes and must be escaped to match literally.
+func AddPattern(pattern string) (*models.IgnorePattern, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is empty")
+	}
+	if _, err := path.Match(strings.ToLower(pattern), ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	result, err := database.DB.Exec("INSERT INTO ignore_patterns (pattern) VALUES (?)", pattern)
+	if err != nil {
+		return nil, fmt.Errorf("add pattern: %w", err)
+	}
+	id, _ := result.LastInsertId()
+	return &models.IgnorePattern{ID: id, Pattern: pattern, CreatedAt: time.Now()}, nil
+}
+
+// DeletePattern removes an ignore pattern.
+func DeletePattern(id int64) error {
+	if _, err := database.DB.Exec("DELETE FROM ignore_patterns WHERE id = ?", id); err != nil {
+		return fmt.Errorf("delete pattern: %w", err)
+	}
+	return nil
+}
+
+// matchesAny reports whether name matches any pattern, case-insensitively.
+func matchesAny(name string, patterns []models.IgnorePattern) bool {
+	name = strings.ToLower(name)
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p.Pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package downloads
+
+import (
+	"reflect"
+	"testing"
+
+	"link-anime/internal/models"
+)
+
+func TestStateIndexLookup(t *testing.T) {
+	states := []models.DownloadState{
+		{ID: 1, Path: "/dl/Kept", Device: 1, Inode: 100, State: StateArchived},
+		{ID: 2, Path: "/dl/Old Name", Device: 1, Inode: 200, State: StateIgnored},
+		{ID: 3, Path: "/dl/Legacy", Inode: 300, State: StateIgnored},
+	}
+	exists := func(p string) bool { return p == "/dl/Kept" }
+	idx := indexStates(states, exists)
+
+	tests := []struct {
+		name string
+		item models.DownloadItem
+		want string
+	}{
+		{"by path", models.DownloadItem{Path: "/dl/Kept", Device: 1, Inode: 100}, StateArchived},
+		{"renamed", models.DownloadItem{Path: "/dl/New Name", Device: 1, Inode: 200}, StateIgnored},
+		{"same inode, other device", models.DownloadItem{Path: "/dl/Other", Device: 2, Inode: 200}, ""},
+		{"inode of an item still on disk", models.DownloadItem{Path: "/dl/Reused", Device: 1, Inode: 100}, ""},
+		{"legacy row without device", models.DownloadItem{Path: "/dl/Moved", Inode: 300}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := idx.lookup(&tt.item)
+			if got != tt.want {
+				t.Errorf("state = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStaleStates(t *testing.T) {
+	states := []models.DownloadState{
+		{ID: 1, Path: "/dl/Present", Device: 1, Inode: 100},
+		{ID: 2, Path: "/dl/Renamed", Device: 1, Inode: 200},
+		{ID: 3, Path: "/dl/Deleted", Device: 1, Inode: 300},
+		{ID: 4, Path: "/dl/Legacy", Inode: 200},
+	}
+	exists := func(p string) bool { return p == "/dl/Present" }
+	present := map[fileKey]bool{{1, 100}: true, {1, 200}: true}
+
+	got := staleStates(states, exists, present)
+	if want := []int64{3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("stale = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,178 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"link-anime/internal/httpx"
+	"link-anime/internal/models"
+	"link-anime/internal/nyaa"
+)
+
+// document covers both RSS 2.0 (<rss><channel><item>) and Atom (<feed><entry>).
+type document struct {
+	XMLName xml.Name
+	Items   []rssItem   `xml:"channel>item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title     string `xml:"title"`
+	Link      string `xml:"link"`
+	GUID      string `xml:"guid"`
+	PubDate   string `xml:"pubDate"`
+	Enclosure struct {
+		URL    string `xml:"url,attr"`
+		Type   string `xml:"type,attr"`
+		Length int64  `xml:"length,attr"`
+	} `xml:"enclosure"`
+
+	// Nyaa namespace, also used by Nyaa mirrors
+	Seeders  string `xml:"https://nyaa.si/xmlns/nyaa seeders"`
+	Leechers string `xml:"https://nyaa.si/xmlns/nyaa leechers"`
+	Size     string `xml:"https://nyaa.si/xmlns/nyaa size"`
+	InfoHash string `xml:"https://nyaa.si/xmlns/nyaa infoHash"`
+}
+
+type atomEntry struct {
+	Title   string `xml:"title"`
+	ID      string `xml:"id"`
+	Updated string `xml:"updated"`
+	Links   []struct {
+		Href   string `xml:"href,attr"`
+		Rel    string `xml:"rel,attr"`
+		Type   string `xml:"type,attr"`
+		Length int64  `xml:"length,attr"`
+	} `xml:"link"`
+}
+
+// client fetches feeds through the indexer proxy, revalidating unchanged ones.
+var client = httpx.New(httpx.Options{Name: "feed", Timeout: 15 * time.Second, RateLimit: true, Cache: true, Proxy: true})
+
+// Fetch downloads and parses an RSS 2.0 or Atom feed.
+func Fetch(feedURL string) ([]models.NyaaResult, error) {
+	resp, err := client.Get(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("feed returned status %d", resp.StatusCode)
+	}
+
+	return Parse(resp.Body)
+}
+
+// Parse reads an RSS 2.0 or Atom feed. Each item's magnet is taken from a
+// magnet link or enclosure, built from an info hash, or falls back to the
+// .torrent URL (qBittorrent accepts both).
+func Parse(r io.Reader) ([]models.NyaaResult, error) {
+	var doc document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse feed: %w", err)
+	}
+
+	var results []models.NyaaResult
+	switch doc.XMLName.Local {
+	case "rss":
+		for _, item := range doc.Items {
+			results = append(results, item.toResult())
+		}
+	case "feed":
+		for _, entry := range doc.Entries {
+			results = append(results, entry.toResult())
+		}
+	default:
+		return nil, fmt.Errorf("parse feed: unsupported root element <%s>", doc.XMLName.Local)
+	}
+
+	return results, nil
+}
+
+func (item rssItem) toResult() models.NyaaResult {
+	seeders, _ := strconv.Atoi(item.Seeders)
+	leechers, _ := strconv.Atoi(item.Leechers)
+
+	res := models.NyaaResult{
+		Title:    strings.TrimSpace(item.Title),
+		Seeders:  seeders,
+		Leechers: leechers,
+		Size:     item.Size,
+		InfoHash: strings.ToLower(strings.TrimSpace(item.InfoHash)),
+	}
+	if item.Size != "" {
+		res.SizeBytes = nyaa.ParseSize(item.Size)
+	} else if item.Enclosure.Length > 0 {
+		res.SizeBytes = item.Enclosure.Length
+	}
+
+	resolveLinks(&res, item.Enclosure.URL, item.Link, item.GUID)
+	return res
+}
+
+func (entry atomEntry) toResult() models.NyaaResult {
+	res := models.NyaaResult{Title: strings.TrimSpace(entry.Title)}
+
+	var links []string
+	for _, l := range entry.Links {
+		// Enclosures and torrent-typed links first
+		if l.Rel == "enclosure" || l.Type == "application/x-bittorrent" {
+			links = append([]string{l.Href}, links...)
+			if l.Length > 0 {
+				res.SizeBytes = l.Length
+			}
+		} else {
+			links = append(links, l.Href)
+		}
+	}
+	links = append(links, entry.ID)
+
+	resolveLinks(&res, links...)
+	return res
+}
+
+// resolveLinks picks the magnet/torrent URL and info hash from candidate links, in priority order.
+func resolveLinks(res *models.NyaaResult, links ...string) {
+	for _, l := range links {
+		if strings.HasPrefix(l, "magnet:") {
+			res.Magnet = l
+			if res.InfoHash == "" {
+				res.InfoHash = MagnetInfoHash(l)
+			}
+			return
+		}
+	}
+
+	for _, l := range links {
+		if strings.HasPrefix(l, "http://") || strings.HasPrefix(l, "https://") {
+			res.TorrentURL = l
+			break
+		}
+	}
+
+	if res.InfoHash != "" {
+		res.Magnet = nyaa.Magnet(res.InfoHash, res.Title)
+	} else {
+		res.Magnet = res.TorrentURL
+	}
+}
+
+// MagnetInfoHash extracts the lowercase hex BTIH from a magnet URI, or "".
+func MagnetInfoHash(magnet string) string {
+	u, err := url.Parse(magnet)
+	if err != nil {
+		return ""
+	}
+	for _, xt := range u.Query()["xt"] {
+		if h, ok := strings.CutPrefix(xt, "urn:btih:"); ok && len(h) == 40 {
+			return strings.ToLower(h)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+)
+
+const hash = "0123456789abcdef0123456789abcdef01234567"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		doc        string
+		title      string
+		magnet     string // prefix
+		torrentURL string
+		infoHash   string
+		sizeBytes  int64
+		seeders    int
+	}{
+		// Nyaa-style RSS with namespace fields
+		{
+			"nyaa rss",
+			`<rss version="2.0" xmlns:nyaa="https://nyaa.si/xmlns/nyaa"><channel><item>
+				<title>[SubsPlease] Frieren - 05 (1080p)</title>
+				<link>https://nyaa.si/download/1.torrent</link>
+				<nyaa:seeders>42</nyaa:seeders>
+				<nyaa:size>1.5 GiB</nyaa:size>
+				<nyaa:infoHash>` + strings.ToUpper(hash) + `</nyaa:infoHash>
+			</item></channel></rss>`,
+			"[SubsPlease] Frieren - 05 (1080p)", "magnet:?xt=urn:btih:" + hash,
+			"https://nyaa.si/download/1.torrent", hash, 1610612736, 42,
+		},
+		// RSS with a magnet enclosure
+		{
+			"rss magnet enclosure",
+			`<rss version="2.0"><channel><item>
+				<title>Show - 01</title>
+				<link>https://tracker.example/details/1</link>
+				<enclosure url="magnet:?xt=urn:btih:` + hash + `&amp;dn=Show" type="application/x-bittorrent" length="1000"/>
+			</item></channel></rss>`,
+			"Show - 01", "magnet:?xt=urn:btih:" + hash, "", hash, 1000, 0,
+		},
+		// RSS with only a .torrent enclosure
+		{
+			"rss torrent enclosure",
+			`<rss version="2.0"><channel><item>
+				<title>Show - 02</title>
+				<enclosure url="https://tracker.example/2.torrent" type="application/x-bittorrent" length="2000"/>
+			</item></channel></rss>`,
+			"Show - 02", "https://tracker.example/2.torrent", "https://tracker.example/2.torrent", "", 2000, 0,
+		},
+		// Atom with a torrent link
+		{
+			"atom",
+			`<feed xmlns="http://www.w3.org/2005/Atom"><entry>
+				<title>Show - 03</title>
+				<id>urn:uuid:1</id>
+				<link rel="alternate" href="https://tracker.example/view/3"/>
+				<link rel="enclosure" type="application/x-bittorrent" href="https://tracker.example/3.torrent" length="3000"/>
+			</entry></feed>`,
+			"Show - 03", "https://tracker.example/3.torrent", "https://tracker.example/3.torrent", "", 3000, 0,
+		},
+	}
+
+	for _, tt := range tests {
+		results, err := Parse(strings.NewReader(tt.doc))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if len(results) != 1 {
+			t.Errorf("%s: got %d results, want 1", tt.name, len(results))
+			continue
+		}
+		r := results[0]
+		if r.Title != tt.title {
+			t.Errorf("%s: title = %q, want %q", tt.name, r.Title, tt.title)
+		}
+		if !strings.HasPrefix(r.Magnet, tt.magnet) {
+			t.Errorf("%s: magnet = %q, want prefix %q", tt.name, r.Magnet, tt.magnet)
+		}
+		if r.TorrentURL != tt.torrentURL {
+			t.Errorf("%s: torrentURL = %q, want %q", tt.name, r.TorrentURL, tt.torrentURL)
+		}
+		if r.InfoHash != tt.infoHash {
+			t.Errorf("%s: infoHash = %q, want %q", tt.name, r.InfoHash, tt.infoHash)
+		}
+		if r.SizeBytes != tt.sizeBytes {
+			t.Errorf("%s: sizeBytes = %d, want %d", tt.name, r.SizeBytes, tt.sizeBytes)
+		}
+		if r.Seeders != tt.seeders {
+			t.Errorf("%s: seeders = %d, want %d", tt.name, r.Seeders, tt.seeders)
+		}
+	}
+}
+
+func TestParseUnsupported(t *testing.T) {
+	if _, err := Parse(strings.NewReader(`<html><body/></html>`)); err == nil {
+		t.Error("expected error for non-feed document")
+	}
+}
@@ -0,0 +1,94 @@
+package httpx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+const (
+	cacheMaxBytes = 16 << 20 // total body bytes kept; the oldest entries are dropped beyond this
+	cacheMaxBody  = 1 << 20  // larger bodies (more than a feed or search page) pass through uncached
+	cacheReadSize = cacheMaxBody + 1
+)
+
+type cacheEntry struct {
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+// response rebuilds a 200 response from the entry for a revalidated request.
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+type responseCache struct {
+	mu       sync.Mutex
+	entries  map[string]*cacheEntry
+	order    []string // insertion order, for eviction
+	size     int      // total body bytes held
+	maxBytes int
+}
+
+var cache = &responseCache{entries: map[string]*cacheEntry{}, maxBytes: cacheMaxBytes}
+
+func (c *responseCache) get(key string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[key]
+}
+
+// store reads a 200 response and remembers it if the server gave a
+// validator. The returned response has the body replaced with the copy read.
+func (c *responseCache) store(key string, resp *http.Response) (*http.Response, error) {
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, cacheReadSize))
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if len(body) > cacheMaxBody {
+		// Too big to keep: hand back what was read followed by the rest
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.entries[key]; ok {
+		c.size -= len(old.body)
+	} else {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = &cacheEntry{etag: etag, lastModified: lastModified, header: resp.Header.Clone(), body: body}
+	c.size += len(body)
+	for c.size > c.maxBytes && len(c.order) > 1 {
+		c.size -= len(c.entries[c.order[0]].body)
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	return resp, nil
+}
@@ -0,0 +1,309 @@
+// Package httpx is the shared client for outbound HTTP. It sets a common
+// User-Agent, rate limits requests per host, retries 5xx and 429 responses
+// with backoff, revalidates cached feeds with ETag and Last-Modified, routes
+// indexer traffic through an optional proxy, and counts requests per client.
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UserAgent is sent with every request.
+const UserAgent = "link-anime/1.0 (+https://github.com/Sayykii/link-anime)"
+
+const (
+	defaultRetries = 2
+	maxRetryAfter  = 30 * time.Second
+	hostBurst      = 5               // requests a host may take at once
+	hostRefill     = 1 * time.Second // then one per this long
+)
+
+// retryBase is the wait before the first retry, doubling after each.
+var retryBase = time.Second
+
+// Options configure a client.
+type Options struct {
+	Name      string        // metrics label, e.g. "nyaa"
+	Timeout   time.Duration // per attempt
+	Retries   int           // retries after the first attempt; 0 = default, negative = none
+	RateLimit bool          // share the per-host rate limit
+	Cache     bool          // revalidate GETs with ETag / Last-Modified
+	Proxy     bool          // use the indexer proxy, if one is set
+}
+
+// Client sends requests with the shared behaviour. It is safe for
+// concurrent use.
+type Client struct {
+	opts Options
+}
+
+// New creates a client.
+func New(opts Options) *Client {
+	if opts.Retries == 0 {
+		opts.Retries = defaultRetries
+	}
+	if opts.Retries < 0 {
+		opts.Retries = 0
+	}
+	return &Client{opts: opts}
+}
+
+// Get fetches a URL.
+func (c *Client) Get(rawURL string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, redactURLError(err)
+	}
+	return c.Do(req)
+}
+
+// redactURLError removes the query and credentials from the URL in a
+// *url.Error, since queries can carry API keys.
+func redactURLError(err error) error {
+	var uerr *url.Error
+	if !errors.As(err, &uerr) {
+		return err
+	}
+	redacted := *uerr
+	if u, perr := url.Parse(uerr.URL); perr == nil {
+		u.RawQuery, u.ForceQuery = "", false
+		redacted.URL = u.Redacted()
+	} else {
+		redacted.URL = "(invalid url)"
+	}
+	return &redacted
+}
+
+// Do sends a request. GET and HEAD are retried on network errors and 5xx;
+// any request is retried on 429, which the server didn't act on. Requests
+// are only retried if their body is replayable (http.NewRequest sets GetBody
+// for the usual readers).
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent)
+	}
+	stat := statFor(c.opts.Name, req.URL.Host)
+
+	var cached *cacheEntry
+	if c.opts.Cache && req.Method == "GET" {
+		if cached = cache.get(req.URL.String()); cached != nil {
+			if cached.etag != "" {
+				req.Header.Set("If-None-Match", cached.etag)
+			}
+			if cached.lastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.lastModified)
+			}
+		}
+	}
+
+	hc := &http.Client{Timeout: c.opts.Timeout, Transport: transport(c.opts.Proxy)}
+	idempotent := req.Method == "GET" || req.Method == "HEAD"
+	// a body that can't be replayed gets one attempt, whose result is returned as is
+	retries := c.opts.Retries
+	if req.Body != nil && req.GetBody == nil {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		if c.opts.RateLimit && waitHost(req.URL.Host) {
+			stat.add(func(s *Stat) { s.Throttled++ })
+		}
+
+		stat.add(func(s *Stat) { s.Requests++ })
+		resp, err := hc.Do(req)
+		err = redactURLError(err)
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			stat.fail(err.Error())
+			if !idempotent || attempt >= retries {
+				return nil, err
+			}
+		case resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode >= 500 && idempotent):
+			stat.fail(fmt.Sprintf("%s returned status %d", req.URL.Host, resp.StatusCode))
+			if attempt >= retries {
+				return resp, nil
+			}
+			wait = retryAfter(resp)
+			resp.Body.Close()
+		case resp.StatusCode == http.StatusNotModified && cached != nil:
+			resp.Body.Close()
+			stat.add(func(s *Stat) { s.CacheHits++ })
+			return cached.response(req), nil
+		default:
+			if resp.StatusCode >= 400 {
+				stat.fail(fmt.Sprintf("%s returned status %d", req.URL.Host, resp.StatusCode))
+			}
+			if c.opts.Cache && req.Method == "GET" && resp.StatusCode == http.StatusOK {
+				return cache.store(req.URL.String(), resp)
+			}
+			return resp, nil
+		}
+
+		if wait == 0 {
+			wait = retryBase << attempt
+		}
+		stat.add(func(s *Stat) { s.Retries++ })
+		time.Sleep(wait)
+	}
+}
+
+// retryAfter reads a Retry-After header in seconds, capped.
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	d := time.Duration(secs) * time.Second
+	if d > maxRetryAfter {
+		d = maxRetryAfter
+	}
+	return d
+}
+
+// --- transports and proxy ---
+
+var (
+	transportMu sync.RWMutex
+	direct      = http.DefaultTransport.(*http.Transport).Clone()
+	proxied     = direct
+	proxyURL    string
+)
+
+func transport(useProxy bool) http.RoundTripper {
+	if !useProxy {
+		return direct
+	}
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+	return proxied
+}
+
+// SetProxy routes clients created with Proxy through an http, https or
+// socks5 proxy. An empty URL removes it.
+func SetProxy(raw string) error {
+	if err := ValidateProxy(raw); err != nil {
+		return err
+	}
+	t := direct
+	if raw != "" {
+		u, _ := url.Parse(raw)
+		t = direct.Clone()
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	transportMu.Lock()
+	proxied, proxyURL = t, raw
+	transportMu.Unlock()
+	return nil
+}
+
+// ValidateProxy checks a proxy URL without applying it.
+func ValidateProxy(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid proxy URL")
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return nil
+	}
+	return fmt.Errorf("proxy must be an http, https or socks5 URL")
+}
+
+// maskedAuth replaces proxy credentials in URLs shown to the UI.
+const maskedAuth = "********"
+
+// MaskProxy hides the credentials in a proxy URL.
+func MaskProxy(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	// url.User would percent-encode the asterisks
+	u.User = nil
+	return u.Scheme + "://" + maskedAuth + "@" + strings.TrimPrefix(u.String(), u.Scheme+"://")
+}
+
+// UnmaskProxy restores the stored credentials into a proxy URL the UI sent
+// back with them masked.
+func UnmaskProxy(submitted, stored string) string {
+	u, err := url.Parse(submitted)
+	if err != nil || u.User == nil || u.User.Username() != maskedAuth {
+		return submitted
+	}
+	old, err := url.Parse(stored)
+	if err != nil || old.User == nil {
+		return submitted
+	}
+	u.User = old.User
+	return u.String()
+}
+
+// Proxy returns the current indexer proxy URL, or "".
+func Proxy() string {
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+	return proxyURL
+}
+
+// --- per-host rate limit ---
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+var (
+	limitMu sync.Mutex
+	buckets = map[string]*bucket{}
+)
+
+// waitHost blocks until the host's bucket has a token, reporting whether it
+// had to wait.
+func waitHost(host string) bool {
+	limitMu.Lock()
+	b, ok := buckets[host]
+	t := now()
+	if !ok {
+		b = &bucket{tokens: hostBurst, last: t}
+		buckets[host] = b
+	}
+	b.tokens += float64(t.Sub(b.last)) / float64(hostRefill)
+	if b.tokens > hostBurst {
+		b.tokens = hostBurst
+	}
+	b.last = t
+	b.tokens--
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens * float64(hostRefill))
+	}
+	limitMu.Unlock()
+
+	if wait <= 0 {
+		return false
+	}
+	time.Sleep(wait)
+	return true
+}
+
+var now = time.Now
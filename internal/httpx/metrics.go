@@ -0,0 +1,81 @@
+package httpx
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stat counts one client's requests to one host since startup.
+type Stat struct {
+	Client      string     `json:"client"`
+	Host        string     `json:"host"`
+	Requests    int        `json:"requests"`  // attempts sent, retries included
+	Failures    int        `json:"failures"`  // network errors and 4xx/5xx responses
+	Retries     int        `json:"retries"`   // attempts repeated after a failure
+	CacheHits   int        `json:"cacheHits"` // 304s answered from the cache
+	Throttled   int        `json:"throttled"` // requests delayed by the host rate limit
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+type statEntry struct {
+	mu   sync.Mutex
+	stat Stat
+}
+
+func (e *statEntry) add(fn func(*Stat)) {
+	e.mu.Lock()
+	fn(&e.stat)
+	e.mu.Unlock()
+}
+
+func (e *statEntry) fail(msg string) {
+	t := now()
+	e.add(func(s *Stat) {
+		s.Failures++
+		s.LastError = msg
+		s.LastErrorAt = &t
+	})
+}
+
+var (
+	statsMu sync.Mutex
+	stats   = map[[2]string]*statEntry{}
+)
+
+func statFor(client, host string) *statEntry {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	key := [2]string{client, host}
+	e, ok := stats[key]
+	if !ok {
+		e = &statEntry{stat: Stat{Client: client, Host: host}}
+		stats[key] = e
+	}
+	return e
+}
+
+// Metrics returns the counters of every client and host, sorted.
+func Metrics() []Stat {
+	statsMu.Lock()
+	entries := make([]*statEntry, 0, len(stats))
+	for _, e := range stats {
+		entries = append(entries, e)
+	}
+	statsMu.Unlock()
+
+	list := make([]Stat, 0, len(entries))
+	for _, e := range entries {
+		e.mu.Lock()
+		list = append(list, e.stat)
+		e.mu.Unlock()
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Client != list[j].Client {
+			return list[i].Client < list[j].Client
+		}
+		return list[i].Host < list[j].Host
+	})
+	return list
+}
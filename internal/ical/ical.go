@@ -0,0 +1,88 @@
+// Package ical writes iCalendar (RFC 5545) feeds for calendar subscriptions.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// Event is a single calendar entry. End defaults to Start when zero.
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Status      string // "CONFIRMED", "TENTATIVE" or "CANCELLED"; optional
+	Categories  []string
+}
+
+const timeFormat = "20060102T150405Z"
+
+// Write encodes events as a VCALENDAR named name.
+func Write(w io.Writer, name string, events []Event) error {
+	bw := bufio.NewWriter(w)
+	now := time.Now().UTC().Format(timeFormat)
+
+	line(bw, "BEGIN:VCALENDAR")
+	line(bw, "VERSION:2.0")
+	line(bw, "PRODID:-//link-anime//calendar//EN")
+	line(bw, "CALSCALE:GREGORIAN")
+	line(bw, "METHOD:PUBLISH")
+	line(bw, "X-WR-CALNAME:"+escape(name))
+
+	for _, e := range events {
+		end := e.End
+		if end.IsZero() {
+			end = e.Start
+		}
+		line(bw, "BEGIN:VEVENT")
+		line(bw, "UID:"+escape(e.UID))
+		line(bw, "DTSTAMP:"+now)
+		line(bw, "DTSTART:"+e.Start.UTC().Format(timeFormat))
+		line(bw, "DTEND:"+end.UTC().Format(timeFormat))
+		line(bw, "SUMMARY:"+escape(e.Summary))
+		if e.Description != "" {
+			line(bw, "DESCRIPTION:"+escape(e.Description))
+		}
+		if e.Status != "" {
+			line(bw, "STATUS:"+e.Status)
+		}
+		if len(e.Categories) > 0 {
+			cats := make([]string, len(e.Categories))
+			for i, c := range e.Categories {
+				cats[i] = escape(c)
+			}
+			line(bw, "CATEGORIES:"+strings.Join(cats, ","))
+		}
+		line(bw, "END:VEVENT")
+	}
+
+	line(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// line writes a content line, folded at 75 octets without splitting UTF-8
+// sequences, terminated by CRLF.
+func line(w *bufio.Writer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	w.WriteString(s + "\r\n")
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escape escapes a TEXT value.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
@@ -0,0 +1,47 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	start := time.Date(2026, 1, 3, 16, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	err := Write(&buf, "Anime", []Event{{
+		UID:         "expected-1-1-5@link-anime",
+		Start:       start,
+		End:         start.Add(30 * time.Minute),
+		Summary:     "Frieren S01E05, expected",
+		Description: "Rule: Frieren; weekly on Saturday\n" + strings.Repeat("é", 60),
+		Status:      "TENTATIVE",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTART:20260103T160000Z\r\n",
+		"DTEND:20260103T163000Z\r\n",
+		`SUMMARY:Frieren S01E05\, expected` + "\r\n",
+		"STATUS:TENTATIVE\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+
+	for _, l := range strings.Split(out, "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("line not folded (%d octets): %q", len(l), l)
+		}
+	}
+	if !strings.Contains(out, `Saturday\n`) {
+		t.Error("newline in description not escaped")
+	}
+}
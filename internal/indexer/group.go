@@ -0,0 +1,141 @@
+package indexer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"link-anime/internal/models"
+	"link-anime/internal/parser"
+	"link-anime/internal/quality"
+)
+
+// LibraryLookup returns the episodes of a show's season already in the library.
+type LibraryLookup func(show string, season int) map[int]bool
+
+// Group parses each result's title and groups the results by show, season
+// and episode, or by batch range. Releases in a group are ranked by the
+// profile (by resolution alone when it is nil), then revision and seeders.
+// Groups keep the order their first result had. inLibrary may be nil.
+func Group(results []models.NyaaResult, profile *models.QualityProfile, inLibrary LibraryLookup) []models.ResultGroup {
+	if profile == nil {
+		profile = &models.QualityProfile{}
+	}
+
+	groups := []models.ResultGroup{}
+	index := map[string]int{}
+	for _, res := range results {
+		rel := parser.ParseRelease(res.Title)
+		season := 1
+		if rel.Season != nil {
+			season = *rel.Season
+		}
+
+		key := fmt.Sprintf("%s|%d|", strings.ToLower(rel.Title), season)
+		switch {
+		case rel.Batch || rel.EpisodeEnd != nil:
+			key += "batch|" + intKey(rel.Episode) + "-" + intKey(rel.EpisodeEnd)
+		case rel.Episode != nil:
+			key += intKey(rel.Episode)
+		default:
+			key += "title|" + strings.ToLower(res.Title) // can't tell, so no grouping
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, models.ResultGroup{
+				Show:       rel.Title,
+				Season:     season,
+				Episode:    rel.Episode,
+				EpisodeEnd: rel.EpisodeEnd,
+				Batch:      rel.Batch || rel.EpisodeEnd != nil,
+			})
+		}
+		groups[i].Releases = append(groups[i].Releases, models.RankedRelease{
+			NyaaResult: res,
+			Group:      rel.Group,
+			Resolution: rel.Resolution,
+			Codec:      rel.Codec,
+			Source:     rel.Source,
+			Revision:   rel.Revision,
+			Score:      quality.Score(profile, rel),
+		})
+	}
+
+	libraries := map[string]map[int]bool{}
+	for i := range groups {
+		g := &groups[i]
+		sort.SliceStable(g.Releases, func(a, b int) bool {
+			ra, rb := g.Releases[a], g.Releases[b]
+			if ra.Score != rb.Score {
+				return ra.Score > rb.Score
+			}
+			if ra.Revision != rb.Revision {
+				return ra.Revision > rb.Revision
+			}
+			return ra.Seeders > rb.Seeders
+		})
+		g.Resolutions = facets(g.Releases, func(r models.RankedRelease) string { return r.Resolution }, false)
+		g.Groups = facets(g.Releases, func(r models.RankedRelease) string { return r.Group }, true)
+
+		if inLibrary != nil && g.Episode != nil && g.Show != "" {
+			key := fmt.Sprintf("%s|%d", strings.ToLower(g.Show), g.Season)
+			have, ok := libraries[key]
+			if !ok {
+				have = inLibrary(g.Show, g.Season)
+				libraries[key] = have
+			}
+			g.InLibrary = covers(have, *g.Episode, g.EpisodeEnd)
+		}
+	}
+	return groups
+}
+
+// facets counts releases per value in rank order, or by seeders if bySeeders.
+func facets(releases []models.RankedRelease, value func(models.RankedRelease) string, bySeeders bool) []models.SearchFacet {
+	list := []models.SearchFacet{}
+	index := map[string]int{}
+	for _, r := range releases {
+		v := value(r)
+		if v == "" {
+			continue
+		}
+		i, ok := index[strings.ToLower(v)]
+		if !ok {
+			i = len(list)
+			index[strings.ToLower(v)] = i
+			list = append(list, models.SearchFacet{Value: v})
+		}
+		list[i].Releases++
+		if r.Seeders > list[i].Seeders {
+			list[i].Seeders = r.Seeders
+		}
+	}
+	if bySeeders {
+		sort.SliceStable(list, func(a, b int) bool { return list[a].Seeders > list[b].Seeders })
+	}
+	return list
+}
+
+// covers reports whether have holds every episode from first to last.
+func covers(have map[int]bool, first int, last *int) bool {
+	end := first
+	if last != nil && *last > end {
+		end = *last
+	}
+	for ep := first; ep <= end; ep++ {
+		if !have[ep] {
+			return false
+		}
+	}
+	return true
+}
+
+func intKey(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return fmt.Sprint(*n)
+}
@@ -0,0 +1,55 @@
+package indexer
+
+import (
+	"testing"
+
+	"link-anime/internal/models"
+)
+
+func TestGroup(t *testing.T) {
+	results := []models.NyaaResult{
+		{Title: "[SubsPlease] Frieren - 05 (720p) [AAAA1111].mkv", Seeders: 300},
+		{Title: "[SubsPlease] Frieren - 05 (1080p) [BBBB2222].mkv", Seeders: 900},
+		{Title: "[Erai-raws] Frieren - 05 [1080p][Multiple Subtitle]", Seeders: 200},
+		{Title: "[SubsPlease] Frieren - 04 (1080p) [CCCC3333].mkv", Seeders: 1200},
+		{Title: "[Judas] Frieren (Season 1) [1080p][HEVC x265 10bit][Batch] (01-28)", Seeders: 50},
+	}
+	have := func(show string, season int) map[int]bool {
+		if show != "Frieren" || season != 1 {
+			t.Errorf("library lookup for %q season %d", show, season)
+		}
+		return map[int]bool{4: true}
+	}
+
+	groups := Group(results, nil, have)
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3: %+v", len(groups), groups)
+	}
+
+	ep5 := groups[0]
+	if ep5.Episode == nil || *ep5.Episode != 5 || ep5.Batch || ep5.InLibrary {
+		t.Errorf("episode 5 group = %+v", ep5)
+	}
+	if len(ep5.Releases) != 3 || ep5.Releases[0].Seeders != 900 || ep5.Releases[2].Resolution != "720p" {
+		t.Errorf("episode 5 ranking = %+v", ep5.Releases)
+	}
+	if len(ep5.Resolutions) != 2 || ep5.Resolutions[0].Value != "1080p" || ep5.Resolutions[0].Releases != 2 {
+		t.Errorf("resolutions = %+v", ep5.Resolutions)
+	}
+	if ep5.Groups[0].Value != "SubsPlease" || ep5.Groups[0].Seeders != 900 {
+		t.Errorf("groups = %+v", ep5.Groups)
+	}
+
+	if !groups[1].InLibrary {
+		t.Errorf("episode 4 should be in the library: %+v", groups[1])
+	}
+	if batch := groups[2]; !batch.Batch || batch.InLibrary {
+		t.Errorf("batch group = %+v", batch)
+	}
+
+	// A profile preferring 720p reorders the episode
+	profile := &models.QualityProfile{Resolutions: []string{"720p", "1080p"}}
+	if top := Group(results, profile, nil)[0].Releases[0]; top.Resolution != "720p" {
+		t.Errorf("profile ranking picked %q", top.Title)
+	}
+}
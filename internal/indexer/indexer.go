@@ -0,0 +1,241 @@
+// Package indexer searches torrent sites through a common interface, so the
+// search endpoint and RSS rules can use Nyaa, Torznab servers such as
+// Jackett and Prowlarr, or several at once.
+package indexer
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"link-anime/internal/models"
+	"link-anime/internal/nyaa"
+)
+
+// Indexer types.
+const (
+	TypeNyaa    = "nyaa"
+	TypeTorznab = "torznab"
+)
+
+// Query is one search. Season and Episode narrow Torznab tv-searches;
+// Category, Filter and User override a Nyaa indexer's own settings.
+type Query struct {
+	Text     string
+	Season   *int
+	Episode  *int
+	Category string
+	Filter   string
+	User     string
+}
+
+// Indexer is a search backend.
+type Indexer interface {
+	Name() string
+	Search(q Query) ([]models.NyaaResult, error)
+}
+
+// New builds an indexer from its configuration.
+func New(cfg models.IndexerConfig) (Indexer, error) {
+	switch cfg.Type {
+	case TypeNyaa:
+		n := &Nyaa{name: cfg.Name, site: cfg.URL, filter: cfg.Filter}
+		if len(cfg.Categories) > 0 {
+			n.category = cfg.Categories[0]
+		}
+		return n, nil
+	case TypeTorznab:
+		return NewTorznab(cfg.Name, cfg.URL, cfg.APIKey, cfg.Categories), nil
+	default:
+		return nil, fmt.Errorf("unknown indexer type %q", cfg.Type)
+	}
+}
+
+// Validate checks an indexer configuration before it is saved.
+func Validate(cfg *models.IndexerConfig) error {
+	cfg.Name = strings.TrimSpace(cfg.Name)
+	if cfg.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if cfg.URL != "" {
+		u, err := url.Parse(cfg.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url must be an http(s) URL")
+		}
+	}
+
+	switch cfg.Type {
+	case TypeNyaa:
+		if len(cfg.Categories) > 1 {
+			return fmt.Errorf("nyaa indexers take a single category")
+		}
+		for _, c := range cfg.Categories {
+			if _, ok := nyaa.Categories[c]; !ok {
+				return fmt.Errorf("unknown nyaa category %q", c)
+			}
+		}
+		switch cfg.Filter {
+		case "", "all", "trusted", "noremakes":
+		default:
+			return fmt.Errorf("unknown nyaa filter %q", cfg.Filter)
+		}
+	case TypeTorznab:
+		if cfg.URL == "" {
+			return fmt.Errorf("url is required for torznab indexers")
+		}
+		for _, c := range cfg.Categories {
+			if _, err := strconv.Atoi(c); err != nil {
+				return fmt.Errorf("torznab categories are numbers, got %q", c)
+			}
+		}
+		if cfg.Filter != "" {
+			return fmt.Errorf("filter only applies to nyaa indexers")
+		}
+	default:
+		return fmt.Errorf("type must be nyaa or torznab")
+	}
+	return nil
+}
+
+// Enabled returns the enabled indexers among ids, or all enabled ones if ids
+// is empty or names only deleted indexers, in priority order. With no
+// indexers configured at all, Nyaa is searched as before indexers existed.
+func Enabled(ids []int64) ([]Indexer, error) {
+	configs, err := List()
+	if err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 {
+		return []Indexer{&Nyaa{name: "Nyaa"}}, nil
+	}
+
+	wanted := map[int64]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	exists := false
+	for _, cfg := range configs {
+		exists = exists || wanted[cfg.ID]
+	}
+	var indexers []Indexer
+	for _, cfg := range configs {
+		if !cfg.Enabled || (exists && !wanted[cfg.ID]) {
+			continue
+		}
+		ix, err := New(cfg)
+		if err != nil {
+			return nil, err
+		}
+		indexers = append(indexers, ix)
+	}
+	if len(indexers) == 0 {
+		return nil, fmt.Errorf("no enabled indexers")
+	}
+	return indexers, nil
+}
+
+// Search queries the indexers at once and merges their results. Indexers
+// that fail are reported by name in failed; err is set only if all of them did.
+func Search(indexers []Indexer, q Query) (results []models.NyaaResult, failed map[string]string, err error) {
+	lists := make([][]models.NyaaResult, len(indexers))
+	errs := make([]error, len(indexers))
+
+	var wg sync.WaitGroup
+	for i, ix := range indexers {
+		wg.Add(1)
+		go func(i int, ix Indexer) {
+			defer wg.Done()
+			lists[i], errs[i] = ix.Search(q)
+			for j := range lists[i] {
+				lists[i][j].Indexer = ix.Name()
+			}
+		}(i, ix)
+	}
+	wg.Wait()
+
+	failed = map[string]string{}
+	var last error
+	for i, e := range errs {
+		if e != nil {
+			failed[indexers[i].Name()] = e.Error()
+			last = e
+		}
+	}
+	if len(indexers) > 0 && len(failed) == len(indexers) {
+		if len(indexers) == 1 {
+			return nil, failed, last
+		}
+		return nil, failed, fmt.Errorf("all %d indexers failed, last: %w", len(indexers), last)
+	}
+	return Merge(lists...), failed, nil
+}
+
+// Merge combines result lists, dropping duplicates by info hash (or torrent
+// URL when there is none). The first copy is kept, with the highest seeder and
+// leecher counts seen. Results are ordered newest first; undated ones go last
+// in their original order.
+func Merge(lists ...[]models.NyaaResult) []models.NyaaResult {
+	merged := []models.NyaaResult{}
+	index := map[string]int{}
+	for _, list := range lists {
+		for _, res := range list {
+			key := res.InfoHash
+			if key == "" {
+				key = res.TorrentURL
+			}
+			if key == "" {
+				key = res.Magnet
+			}
+			if i, ok := index[key]; ok && key != "" {
+				if res.Seeders > merged[i].Seeders {
+					merged[i].Seeders = res.Seeders
+				}
+				if res.Leechers > merged[i].Leechers {
+					merged[i].Leechers = res.Leechers
+				}
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, res)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		a, b := merged[i].Date, merged[j].Date
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return a.After(*b)
+	})
+	return merged
+}
+
+// Nyaa searches a Nyaa site.
+type Nyaa struct {
+	name     string
+	site     string // empty = the configured Nyaa site
+	category string
+	filter   string
+}
+
+func (n *Nyaa) Name() string { return n.name }
+
+func (n *Nyaa) Search(q Query) ([]models.NyaaResult, error) {
+	opts := nyaa.SearchOptions{
+		Query:    q.Text,
+		Category: n.category,
+		Filter:   n.filter,
+		User:     q.User,
+		Site:     n.site,
+	}
+	if q.Category != "" {
+		opts.Category = q.Category
+	}
+	if q.Filter != "" {
+		opts.Filter = q.Filter
+	}
+	return nyaa.SearchWith(opts)
+}
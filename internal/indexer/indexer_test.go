@@ -0,0 +1,147 @@
+package indexer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"link-anime/internal/httpx"
+	"link-anime/internal/models"
+)
+
+func TestMerge(t *testing.T) {
+	day := func(d int) *time.Time {
+		tm := time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+		return &tm
+	}
+	a := []models.NyaaResult{
+		{Title: "A", InfoHash: "aaa", Seeders: 10, Date: day(1), Indexer: "nyaa"},
+		{Title: "B", InfoHash: "bbb", Seeders: 5, Date: day(3), Indexer: "nyaa"},
+	}
+	b := []models.NyaaResult{
+		{Title: "A (mirror)", InfoHash: "aaa", Seeders: 20, Leechers: 4, Date: day(1), Indexer: "tosho"},
+		{Title: "C", TorrentURL: "http://x/c.torrent", Indexer: "tosho"},
+		{Title: "D", InfoHash: "ddd", Date: day(2), Indexer: "tosho"},
+	}
+
+	got := Merge(a, b)
+	var titles []string
+	for _, r := range got {
+		titles = append(titles, r.Title)
+	}
+	if want := []string{"B", "D", "A", "C"}; len(titles) != len(want) || titles[0] != want[0] || titles[1] != want[1] || titles[2] != want[2] || titles[3] != want[3] {
+		t.Fatalf("merged order = %v, want %v", titles, want)
+	}
+	if got[2].Indexer != "nyaa" || got[2].Seeders != 20 || got[2].Leechers != 4 {
+		t.Errorf("duplicate kept %+v", got[2])
+	}
+}
+
+const capsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<caps>
+  <searching>
+    <search available="yes" supportedParams="q"/>
+    <tv-search available="yes" supportedParams="q,season,ep"/>
+  </searching>
+  <categories>
+    <category id="5000" name="TV"><subcat id="5070" name="TV/Anime"/></category>
+  </categories>
+</caps>`
+
+const resultsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:torznab="http://torznab.com/schemas/2015/feed">
+<channel>
+<item>
+  <title>[Group] Show - 05 (1080p)</title>
+  <guid>https://tosho.example/view/1</guid>
+  <link>http://prowlarr:9696/1/download?file=x</link>
+  <comments>https://tosho.example/view/1</comments>
+  <pubDate>Fri, 06 Oct 2023 16:00:00 +0000</pubDate>
+  <size>1503238553</size>
+  <torznab:attr name="seeders" value="120"/>
+  <torznab:attr name="peers" value="130"/>
+  <torznab:attr name="infohash" value="0123456789ABCDEF0123456789ABCDEF01234567"/>
+  <torznab:attr name="grabs" value="900"/>
+</item>
+</channel>
+</rss>`
+
+func TestTorznab(t *testing.T) {
+	var last map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("apikey") != "secret" {
+			w.Write([]byte(`<error code="100" description="Invalid API Key"/>`))
+			return
+		}
+		if q.Get("t") == "caps" {
+			w.Write([]byte(capsXML))
+			return
+		}
+		last = map[string]string{"t": q.Get("t"), "season": q.Get("season"), "ep": q.Get("ep"), "cat": q.Get("cat")}
+		w.Write([]byte(resultsXML))
+	}))
+	defer srv.Close()
+
+	tz := NewTorznab("tosho", srv.URL+"/1/api", "secret", []string{"5070"})
+	caps, err := tz.Caps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !caps.TVSearch || len(caps.Categories) != 1 || caps.Categories[0].Subcats[0].ID != "5070" {
+		t.Errorf("caps = %+v", caps)
+	}
+
+	season, ep := 1, 5
+	results, err := tz.Search(Query{Text: "Show", Season: &season, Episode: &ep})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last["t"] != "tvsearch" || last["season"] != "1" || last["ep"] != "5" || last["cat"] != "5070" {
+		t.Errorf("search params = %v", last)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results", len(results))
+	}
+	r := results[0]
+	if r.InfoHash != "0123456789abcdef0123456789abcdef01234567" || r.Seeders != 120 || r.Leechers != 10 ||
+		r.Downloads != 900 || r.Size != "1.4 GiB" || r.Date == nil {
+		t.Errorf("result = %+v", r)
+	}
+	if r.Magnet[:20] != "magnet:?xt=urn:btih:" {
+		t.Errorf("magnet = %q", r.Magnet)
+	}
+
+	if _, err := tz.Search(Query{Text: "Show"}); err != nil || last["t"] != "search" {
+		t.Errorf("plain search used %q: %v", last["t"], err)
+	}
+
+	bad := NewTorznab("bad", srv.URL+"/1/api", "wrong", nil)
+	if _, err := bad.Search(Query{Text: "Show"}); err == nil {
+		t.Error("error document was not reported")
+	}
+}
+
+func TestTorznabErrorHidesAPIKey(t *testing.T) {
+	// A closed port: the request fails before any response
+	srv := httptest.NewServer(http.NotFoundHandler())
+	endpoint := srv.URL + "/api"
+	srv.Close()
+
+	const key = "s3cret-api-key"
+	tz := NewTorznab("closed", endpoint, key, nil)
+	_, err := tz.Search(Query{Text: "frieren"})
+	if err == nil {
+		t.Fatal("expected an error from an unreachable indexer")
+	}
+	if strings.Contains(err.Error(), key) {
+		t.Errorf("error leaks the API key: %v", err)
+	}
+	for _, s := range httpx.Metrics() {
+		if strings.Contains(s.LastError, key) {
+			t.Errorf("metrics leak the API key: %s", s.LastError)
+		}
+	}
+}
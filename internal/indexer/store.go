@@ -0,0 +1,102 @@
+package indexer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"link-anime/internal/database"
+	"link-anime/internal/models"
+)
+
+const indexerColumns = `id, name, type, url, api_key, categories, filter, priority, enabled, created_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanIndexer(row rowScanner) (models.IndexerConfig, error) {
+	var c models.IndexerConfig
+	var categories string
+	err := row.Scan(&c.ID, &c.Name, &c.Type, &c.URL, &c.APIKey, &categories,
+		&c.Filter, &c.Priority, &c.Enabled, &c.CreatedAt)
+	if err != nil {
+		return c, err
+	}
+	json.Unmarshal([]byte(categories), &c.Categories)
+	return c, nil
+}
+
+// List returns all configured indexers in priority order.
+func List() ([]models.IndexerConfig, error) {
+	rows, err := database.DB.Query(`SELECT ` + indexerColumns + ` FROM indexers ORDER BY priority, id`)
+	if err != nil {
+		return nil, fmt.Errorf("list indexers: %w", err)
+	}
+	defer rows.Close()
+
+	var configs []models.IndexerConfig
+	for rows.Next() {
+		c, err := scanIndexer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan indexer: %w", err)
+		}
+		configs = append(configs, c)
+	}
+	return configs, nil
+}
+
+// Get returns an indexer by ID, or nil if it does not exist.
+func Get(id int64) (*models.IndexerConfig, error) {
+	c, err := scanIndexer(database.DB.QueryRow(`SELECT `+indexerColumns+` FROM indexers WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get indexer: %w", err)
+	}
+	return &c, nil
+}
+
+// Create inserts a new indexer.
+func Create(c *models.IndexerConfig) error {
+	result, err := database.DB.Exec(`
+		INSERT INTO indexers (name, type, url, api_key, categories, filter, priority, enabled)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, c.Name, c.Type, c.URL, c.APIKey, encode(c.Categories), c.Filter, c.Priority, c.Enabled)
+	if err != nil {
+		return fmt.Errorf("create indexer: %w", err)
+	}
+	c.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// Update saves changes to an existing indexer.
+func Update(c *models.IndexerConfig) error {
+	_, err := database.DB.Exec(`
+		UPDATE indexers SET name = ?, type = ?, url = ?, api_key = ?, categories = ?,
+		       filter = ?, priority = ?, enabled = ?
+		WHERE id = ?
+	`, c.Name, c.Type, c.URL, c.APIKey, encode(c.Categories), c.Filter, c.Priority, c.Enabled, c.ID)
+	if err != nil {
+		return fmt.Errorf("update indexer: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an indexer. Rules that named it search their remaining
+// indexers, or all enabled ones if none remain.
+func Delete(id int64) error {
+	if _, err := database.DB.Exec("DELETE FROM indexers WHERE id = ?", id); err != nil {
+		return fmt.Errorf("delete indexer: %w", err)
+	}
+	return nil
+}
+
+func encode(list []string) string {
+	if len(list) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(list)
+	return string(data)
+}
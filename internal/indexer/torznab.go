@@ -0,0 +1,292 @@
+package indexer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"link-anime/internal/feed"
+	"link-anime/internal/httpx"
+	"link-anime/internal/models"
+	"link-anime/internal/nyaa"
+)
+
+// Torznab searches a Torznab API, as served by Jackett and Prowlarr.
+type Torznab struct {
+	name       string
+	endpoint   string // the API URL, e.g. http://prowlarr:9696/1/api
+	apiKey     string
+	categories []string
+	client     *httpx.Client
+
+	mu   sync.Mutex
+	caps *Caps
+}
+
+// Caps is what a Torznab server says it supports.
+type Caps struct {
+	Search         bool       `json:"search"`
+	TVSearch       bool       `json:"tvSearch"`
+	TVSearchParams []string   `json:"tvSearchParams"` // e.g. "q", "season", "ep"
+	Categories     []Category `json:"categories"`
+}
+
+// Category is a Torznab category, with its subcategories.
+type Category struct {
+	ID      string     `json:"id"`
+	Name    string     `json:"name"`
+	Subcats []Category `json:"subcats,omitempty"`
+}
+
+// NewTorznab creates a Torznab client. categories limits searches to those
+// category numbers; empty searches all.
+func NewTorznab(name, endpoint, apiKey string, categories []string) *Torznab {
+	return &Torznab{
+		name:       name,
+		endpoint:   endpoint,
+		apiKey:     apiKey,
+		categories: categories,
+		client:     httpx.New(httpx.Options{Name: "torznab", Timeout: 30 * time.Second, RateLimit: true, Proxy: true}),
+	}
+}
+
+func (t *Torznab) Name() string { return t.name }
+
+type capsDoc struct {
+	Searching struct {
+		Search   searchCap `xml:"search"`
+		TVSearch searchCap `xml:"tv-search"`
+	} `xml:"searching"`
+	Categories []struct {
+		ID      string `xml:"id,attr"`
+		Name    string `xml:"name,attr"`
+		Subcats []struct {
+			ID   string `xml:"id,attr"`
+			Name string `xml:"name,attr"`
+		} `xml:"subcat"`
+	} `xml:"categories>category"`
+}
+
+type searchCap struct {
+	Available       string `xml:"available,attr"`
+	SupportedParams string `xml:"supportedParams,attr"`
+}
+
+// Caps fetches the server's capabilities. They are cached once fetched.
+func (t *Torznab) Caps() (*Caps, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.caps != nil {
+		return t.caps, nil
+	}
+
+	body, err := t.get(url.Values{"t": {"caps"}})
+	if err != nil {
+		return nil, err
+	}
+	var doc capsDoc
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("torznab %s: parse caps: %w", t.name, err)
+	}
+
+	caps := &Caps{
+		Search:   doc.Searching.Search.Available == "yes",
+		TVSearch: doc.Searching.TVSearch.Available == "yes",
+	}
+	for _, p := range strings.Split(doc.Searching.TVSearch.SupportedParams, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			caps.TVSearchParams = append(caps.TVSearchParams, p)
+		}
+	}
+	for _, c := range doc.Categories {
+		cat := Category{ID: c.ID, Name: c.Name}
+		for _, sc := range c.Subcats {
+			cat.Subcats = append(cat.Subcats, Category{ID: sc.ID, Name: sc.Name})
+		}
+		caps.Categories = append(caps.Categories, cat)
+	}
+	t.caps = caps
+	return caps, nil
+}
+
+func (c *Caps) tvParam(name string) bool {
+	for _, p := range c.TVSearchParams {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+type torznabFeed struct {
+	Items []torznabItem `xml:"channel>item"`
+}
+
+type torznabItem struct {
+	Title     string `xml:"title"`
+	GUID      string `xml:"guid"`
+	Link      string `xml:"link"`
+	Comments  string `xml:"comments"`
+	PubDate   string `xml:"pubDate"`
+	Size      int64  `xml:"size"`
+	Enclosure struct {
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+	} `xml:"enclosure"`
+	Attrs []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	} `xml:"http://torznab.com/schemas/2015/feed attr"`
+}
+
+// Search runs a tv-search when the query has a season or episode and the
+// server supports it, and a plain search otherwise.
+func (t *Torznab) Search(q Query) ([]models.NyaaResult, error) {
+	params := url.Values{"t": {"search"}, "q": {q.Text}}
+	if q.Season != nil || q.Episode != nil {
+		if caps, err := t.Caps(); err == nil && caps.TVSearch {
+			params.Set("t", "tvsearch")
+			if q.Season != nil && caps.tvParam("season") {
+				params.Set("season", strconv.Itoa(*q.Season))
+			}
+			if q.Episode != nil && caps.tvParam("ep") {
+				params.Set("ep", strconv.Itoa(*q.Episode))
+			}
+		}
+	}
+	if len(t.categories) > 0 {
+		params.Set("cat", strings.Join(t.categories, ","))
+	}
+
+	body, err := t.get(params)
+	if err != nil {
+		return nil, err
+	}
+	var doc torznabFeed
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("torznab %s: parse results: %w", t.name, err)
+	}
+
+	results := make([]models.NyaaResult, 0, len(doc.Items))
+	for _, item := range doc.Items {
+		results = append(results, item.toResult())
+	}
+	return results, nil
+}
+
+// get calls the API and returns the body, turning Torznab error documents
+// into errors.
+func (t *Torznab) get(params url.Values) ([]byte, error) {
+	u, err := url.Parse(t.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("torznab %s: bad url: %w", t.name, err)
+	}
+	query := u.Query()
+	for k, v := range params {
+		query[k] = v
+	}
+	if t.apiKey != "" {
+		query.Set("apikey", t.apiKey)
+	}
+	u.RawQuery = query.Encode()
+
+	// httpx strips the query, and with it the API key, from URL errors
+	resp, err := t.client.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("torznab %s: %w", t.name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, fmt.Errorf("torznab %s: %w", t.name, err)
+	}
+
+	var apiErr struct {
+		XMLName     xml.Name
+		Code        string `xml:"code,attr"`
+		Description string `xml:"description,attr"`
+	}
+	if xml.Unmarshal(body, &apiErr) == nil && apiErr.XMLName.Local == "error" {
+		return nil, fmt.Errorf("torznab %s: error %s: %s", t.name, apiErr.Code, apiErr.Description)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("torznab %s returned status %d", t.name, resp.StatusCode)
+	}
+	return body, nil
+}
+
+// toResult converts an item, preferring a magnet link over the .torrent
+// download (which for Jackett and Prowlarr is a proxied URL).
+func (item torznabItem) toResult() models.NyaaResult {
+	attrs := map[string]string{}
+	for _, a := range item.Attrs {
+		attrs[a.Name] = a.Value
+	}
+
+	res := models.NyaaResult{
+		Title:      item.Title,
+		TorrentURL: item.Link,
+		InfoHash:   strings.ToLower(attrs["infohash"]),
+		ViewURL:    item.Comments,
+		Category:   attrs["category"],
+	}
+	if res.TorrentURL == "" {
+		res.TorrentURL = item.Enclosure.URL
+	}
+
+	switch {
+	case strings.HasPrefix(attrs["magneturl"], "magnet:"):
+		res.Magnet = attrs["magneturl"]
+	case strings.HasPrefix(res.TorrentURL, "magnet:"):
+		res.Magnet = res.TorrentURL
+	case res.InfoHash != "":
+		res.Magnet = nyaa.Magnet(res.InfoHash, item.Title)
+	default:
+		res.Magnet = res.TorrentURL
+	}
+	if res.InfoHash == "" {
+		res.InfoHash = feed.MagnetInfoHash(res.Magnet)
+	}
+
+	res.SizeBytes = item.Size
+	if res.SizeBytes == 0 {
+		res.SizeBytes, _ = strconv.ParseInt(attrs["size"], 10, 64)
+	}
+	if res.SizeBytes == 0 {
+		res.SizeBytes = item.Enclosure.Length
+	}
+	if res.SizeBytes > 0 {
+		res.Size = formatSize(res.SizeBytes)
+	}
+
+	res.Seeders, _ = strconv.Atoi(attrs["seeders"])
+	if peers, err := strconv.Atoi(attrs["peers"]); err == nil && peers >= res.Seeders {
+		res.Leechers = peers - res.Seeders
+	}
+	res.Downloads, _ = strconv.Atoi(attrs["grabs"])
+	if t, err := time.Parse(time.RFC1123Z, strings.TrimSpace(item.PubDate)); err == nil {
+		res.Date = &t
+	}
+	return res
+}
+
+// formatSize renders bytes the way Nyaa lists sizes, e.g. "1.4 GiB".
+func formatSize(n int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	size := float64(n)
+	i := 0
+	for size >= 1024 && i < len(units)-1 {
+		size /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", size, units[i])
+}
@@ -84,8 +84,11 @@ func linkSingle(sourcePath, destDir string, req models.LinkRequest, hub *ws.Hub)
 			destFile := filepath.Join(destDir, filename)
 
 			status := linkFile(srcFile, destFile, req.DryRun, result)
-			if status == "linked" {
+			switch status {
+			case "linked":
 				linkedFiles = append(linkedFiles, destFile)
+			case "skipped":
+				result.SkippedFiles = append(result.SkippedFiles, destFile)
 			}
 
 			if hub != nil {
@@ -111,8 +114,11 @@ func linkSingle(sourcePath, destDir string, req models.LinkRequest, hub *ws.Hub)
 		filename := filepath.Base(sourcePath)
 		destFile := filepath.Join(destDir, filename)
 		status := linkFile(sourcePath, destFile, req.DryRun, result)
-		if status == "linked" {
+		switch status {
+		case "linked":
 			linkedFiles = append(linkedFiles, destFile)
+		case "skipped":
+			result.SkippedFiles = append(result.SkippedFiles, destFile)
 		}
 
 		if hub != nil {
@@ -182,6 +188,7 @@ func linkMultiSeason(sourcePath, mediaDir, showName string, seasonDirs map[int]s
 		combined.Failed += r.Failed
 		combined.Size += r.Size
 		combined.Files = append(combined.Files, r.Files...)
+		combined.SkippedFiles = append(combined.SkippedFiles, r.SkippedFiles...)
 	}
 
 	return combined, nil
@@ -267,8 +274,9 @@ func writeHistory(req models.LinkRequest, result *models.LinkResult, sourcePath
 	return nil
 }
 
-// getFileSafety checks the hardlink count of a file and returns safety info.
-func getFileSafety(path string) models.FileSafetyInfo {
+// FileSafety checks the hardlink count of a file. A file is safe to remove
+// when another link to its data remains.
+func FileSafety(path string) models.FileSafetyInfo {
 	info := models.FileSafetyInfo{Path: path}
 	var stat syscall.Stat_t
 	if err := syscall.Stat(path, &stat); err != nil {
@@ -295,7 +303,7 @@ func UnlinkPreview(targetDir string) (*models.UnlinkPreview, error) {
 			return nil
 		}
 
-		safety := getFileSafety(path)
+		safety := FileSafety(path)
 		if safety.Safe {
 			preview.SafeFiles = append(preview.SafeFiles, safety)
 		} else {
@@ -326,7 +334,7 @@ func Unlink(targetDir string, force bool) (*models.LinkResult, error) {
 
 		// Check hardlink safety
 		if !force {
-			safety := getFileSafety(path)
+			safety := FileSafety(path)
 			if !safety.Safe {
 				result.Skipped++
 				return nil
@@ -379,7 +387,7 @@ func UndoPreview() (*models.UnlinkPreview, *models.HistoryEntry, error) {
 			continue
 		}
 
-		safety := getFileSafety(filePath)
+		safety := FileSafety(filePath)
 		if safety.Safe {
 			preview.SafeFiles = append(preview.SafeFiles, safety)
 		} else {
@@ -424,7 +432,7 @@ func Undo(force bool) (*models.LinkResult, *models.HistoryEntry, error) {
 
 		// Check hardlink safety
 		if !force {
-			safety := getFileSafety(filePath)
+			safety := FileSafety(filePath)
 			if !safety.Safe {
 				result.Skipped++
 				continue
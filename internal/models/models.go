@@ -31,6 +31,34 @@ type DownloadItem struct {
 	IsDir      bool   `json:"isDir"`
 	VideoCount int    `json:"videoCount"`
 	Size       int64  `json:"size"`
+	Device     uint64 `json:"-"`
+	Inode      uint64 `json:"-"`
+	State      string `json:"state,omitempty"` // "ignored", "archived", "linked_elsewhere"; empty when active
+
+	// Enrichment fields, only populated when requested with ?enrich=true
+	Parsed        *ParseResult   `json:"parsed,omitempty"`
+	MatchedShow   string         `json:"matchedShow,omitempty"`   // best-matching library show or movie
+	SuggestedType string         `json:"suggestedType,omitempty"` // "series" or "movie"
+	LinkStatus    string         `json:"linkStatus,omitempty"`    // "none", "partial", "full"
+	LinkedCount   int            `json:"linkedCount,omitempty"`   // videos already hardlinked into the library
+	Torrent       *TorrentStatus `json:"torrent,omitempty"`       // matching qBittorrent torrent, if any
+}
+
+// DownloadState records a persistent state for a download item, keyed by path and inode.
+type DownloadState struct {
+	ID        int64     `json:"id"`
+	Path      string    `json:"path"`
+	Device    uint64    `json:"device"`
+	Inode     uint64    `json:"inode"`
+	State     string    `json:"state"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// IgnorePattern is a glob matched against download item names.
+type IgnorePattern struct {
+	ID        int64     `json:"id"`
+	Pattern   string    `json:"pattern"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 // LinkRequest is the payload for creating hardlinks.
@@ -50,6 +78,9 @@ type LinkResult struct {
 	Size    int64    `json:"size"`
 	DestDir string   `json:"destDir"`
 	Files   []string `json:"files"`
+
+	// SkippedFiles are destinations that already existed and were left as is.
+	SkippedFiles []string `json:"skippedFiles,omitempty"`
 }
 
 // HistoryEntry records a past link operation.
@@ -88,6 +119,25 @@ type LibraryStats struct {
 	Size     int64 `json:"size"`
 }
 
+// DiskInfo describes the filesystem holding one of the configured roots.
+type DiskInfo struct {
+	Name       string   `json:"name"` // "download", "media", "movies"
+	Path       string   `json:"path"`
+	Total      uint64   `json:"total"`
+	Free       uint64   `json:"free"` // available to unprivileged users
+	Used       uint64   `json:"used"`
+	Device     uint64   `json:"device"`
+	SharedWith []string `json:"sharedWith,omitempty"` // other roots on the same filesystem
+	Error      string   `json:"error,omitempty"`
+}
+
+// DiskReport summarizes disk usage across all roots.
+type DiskReport struct {
+	Disks        []DiskInfo `json:"disks"`
+	Hardlinkable bool       `json:"hardlinkable"` // all roots share the download root's filesystem
+	Low          bool       `json:"low"`          // free space below the configured threshold
+}
+
 // Settings represents user-configurable settings stored in DB.
 type Settings struct {
 	QbitURL      string `json:"qbitUrl"`
@@ -100,6 +150,15 @@ type Settings struct {
 	DownloadDir  string `json:"downloadDir"`
 	MediaDir     string `json:"mediaDir"`
 	MoviesDir    string `json:"moviesDir"`
+
+	MinFreeSpaceGB int `json:"minFreeSpaceGb"` // pause RSS grabs below this; 0 disables
+
+	CleanupEnabled       bool `json:"cleanupEnabled"`
+	CleanupIntervalHours int  `json:"cleanupIntervalHours"`
+
+	RSSPollIntervalMinutes int `json:"rssPollIntervalMinutes"` // default for rules without their own interval
+
+	IndexerProxy string `json:"indexerProxy"` // http(s):// or socks5:// proxy for indexers and feeds
 }
 
 // WSMessage is a typed WebSocket message.
@@ -127,27 +186,223 @@ type TorrentStatus struct {
 	Size     int64   `json:"size"`
 	ETA      int     `json:"eta"`
 	Ratio    float64 `json:"ratio"`
+
+	Category     string `json:"category"`
+	SavePath     string `json:"savePath"`
+	ContentPath  string `json:"contentPath"`
+	SeedingTime  int64  `json:"seedingTime"`  // seconds spent seeding
+	CompletionOn int64  `json:"completionOn"` // unix time, <= 0 if incomplete
+}
+
+// TorrentFile is a single file inside a torrent.
+type TorrentFile struct {
+	Name     string  `json:"name"` // path relative to the torrent's save path
+	Size     int64   `json:"size"`
+	Progress float64 `json:"progress"`
+}
+
+// CleanupPolicy is the seeding requirement for torrents in a qBit category.
+// An empty Category is the default for categories without their own policy.
+type CleanupPolicy struct {
+	Category       string  `json:"category"`
+	MinRatio       float64 `json:"minRatio"`
+	MinSeedMinutes int     `json:"minSeedMinutes"`
+}
+
+// CleanupCandidate is a completed torrent considered for removal.
+type CleanupCandidate struct {
+	Torrent     TorrentStatus `json:"torrent"`
+	VideoFiles  int           `json:"videoFiles"`
+	LinkedFiles int           `json:"linkedFiles"`
+	Eligible    bool          `json:"eligible"`
+	Reason      string        `json:"reason,omitempty"` // why it is not eligible
+}
+
+// CleanupResult summarizes a cleanup run.
+type CleanupResult struct {
+	Removed []string `json:"removed"`
+	Failed  []string `json:"failed"`
+	Size    int64    `json:"size"`
 }
 
 // NyaaResult represents a search result from Nyaa.
 type NyaaResult struct {
-	Title    string `json:"title"`
-	Magnet   string `json:"magnet"`
-	Size     string `json:"size"`
-	Seeders  int    `json:"seeders"`
-	Leechers int    `json:"leechers"`
+	Title      string `json:"title"`
+	Magnet     string `json:"magnet"`
+	TorrentURL string `json:"torrentUrl,omitempty"`
+	InfoHash   string `json:"infoHash,omitempty"` // lowercase hex BTIH
+	Size       string `json:"size"`
+	SizeBytes  int64  `json:"sizeBytes,omitempty"`
+	Seeders    int    `json:"seeders"`
+	Leechers   int    `json:"leechers"`
+
+	// Listing details, when the feed provides them
+	ViewURL      string     `json:"viewUrl,omitempty"`
+	Category     string     `json:"category,omitempty"` // Nyaa category ID, e.g. "1_2"
+	CategoryName string     `json:"categoryName,omitempty"`
+	Date         *time.Time `json:"date,omitempty"` // posted
+	Downloads    int        `json:"downloads,omitempty"`
+	Trusted      bool       `json:"trusted,omitempty"`
+	Remake       bool       `json:"remake,omitempty"`
+
+	Indexer string `json:"indexer,omitempty"` // name of the indexer that found it, for merged searches
+}
+
+// ResultGroup is the search results for one episode, batch or other release
+// of a show, best first.
+type ResultGroup struct {
+	Show        string          `json:"show"`
+	Season      int             `json:"season"`
+	Episode     *int            `json:"episode,omitempty"`
+	EpisodeEnd  *int            `json:"episodeEnd,omitempty"` // last episode of a batch range
+	Batch       bool            `json:"batch"`
+	InLibrary   bool            `json:"inLibrary"`   // every episode it covers is already linked
+	Resolutions []SearchFacet   `json:"resolutions"` // best first
+	Groups      []SearchFacet   `json:"groups"`      // release groups, most seeded first
+	Releases    []RankedRelease `json:"releases"`
+}
+
+// SearchFacet counts the releases in a group sharing a resolution or release group.
+type SearchFacet struct {
+	Value    string `json:"value"`
+	Releases int    `json:"releases"`
+	Seeders  int    `json:"seeders"` // most seeders of any of them
+}
+
+// RankedRelease is a search result with its parsed release fields and score.
+type RankedRelease struct {
+	NyaaResult
+	Group      string `json:"group,omitempty"`
+	Resolution string `json:"resolution,omitempty"`
+	Codec      string `json:"codec,omitempty"`
+	Source     string `json:"source,omitempty"`
+	Revision   int    `json:"revision"`
+	Score      int    `json:"score"`
+}
+
+// IndexerConfig is a configured search backend.
+type IndexerConfig struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`                 // "nyaa" or "torznab"
+	URL        string    `json:"url"`                  // site or Torznab endpoint; empty = the configured Nyaa site
+	APIKey     string    `json:"apiKey,omitempty"`     // Torznab only
+	Categories []string  `json:"categories,omitempty"` // Nyaa category ID, or Torznab category numbers
+	Filter     string    `json:"filter,omitempty"`     // Nyaa only: "trusted", "noremakes" or "" for all
+	Priority   int       `json:"priority"`             // lower first; earlier indexers win duplicate results
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// TorrentPreview describes a torrent's contents and how linking it would go,
+// before it is downloaded.
+type TorrentPreview struct {
+	Name        string               `json:"name"`
+	InfoHash    string               `json:"infoHash"`
+	TotalSize   int64                `json:"totalSize"`
+	PieceLength int64                `json:"pieceLength"`
+	Type        string               `json:"type"` // "series" or "movie", as planned
+	ShowName    string               `json:"showName"`
+	Season      int                  `json:"season"` // default season, series only
+	HasSubs     bool                 `json:"hasSubs"`
+	HasFonts    bool                 `json:"hasFonts"`
+	Linked      int                  `json:"linked"`  // files the plan would link
+	Skipped     int                  `json:"skipped"` // files it would leave out
+	Files       []TorrentPreviewFile `json:"files"`
+}
+
+// TorrentPreviewFile is one file of a previewed torrent and where it would go.
+type TorrentPreviewFile struct {
+	Path    string `json:"path"` // relative to the torrent root, "/"-separated
+	Size    int64  `json:"size"`
+	Kind    string `json:"kind"` // "video", "subtitle", "font" or "other"
+	Link    bool   `json:"link"`
+	Dest    string `json:"dest,omitempty"`    // library path, relative to the media or movies dir
+	Season  *int   `json:"season,omitempty"`  // series only
+	Episode *int   `json:"episode,omitempty"` // as parsed from the file name
+	Reason  string `json:"reason,omitempty"`  // why the file would be skipped
 }
 
 // RSSRule defines an auto-download rule.
 type RSSRule struct {
-	ID         int64      `json:"id"`
-	Name       string     `json:"name"`
-	Query      string     `json:"query"`
-	ShowName   string     `json:"showName"`
-	Season     int        `json:"season"`
-	MediaType  string     `json:"mediaType"`
-	MinSeeders int        `json:"minSeeders"`
-	Resolution string     `json:"resolution,omitempty"`
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Query      string `json:"query"`
+	ShowName   string `json:"showName"`
+	Season     int    `json:"season"`
+	MediaType  string `json:"mediaType"`
+	MinSeeders int    `json:"minSeeders"`
+	Resolution string `json:"resolution,omitempty"`
+
+	// Feed source. SourceType is "nyaa" (search, the default), "nyaa_user"
+	// (an uploader's feed, optionally narrowed by Query), "url" (any RSS/Atom
+	// feed) or "indexers" (Query searched on the configured indexers).
+	SourceType   string  `json:"sourceType"`
+	NyaaCategory string  `json:"nyaaCategory,omitempty"` // e.g. "1_2"; empty = anime English-translated
+	NyaaFilter   string  `json:"nyaaFilter,omitempty"`   // "trusted" (default), "noremakes" or "all"
+	FeedUser     string  `json:"feedUser,omitempty"`     // Nyaa uploader for "nyaa_user"
+	FeedURL      string  `json:"feedUrl,omitempty"`      // feed URL for "url"
+	Indexers     []int64 `json:"indexers,omitempty"`     // indexer IDs for "indexers"; empty = all enabled
+
+	// Filters, matched against the release title and its parsed fields
+	IncludePattern string   `json:"includePattern,omitempty"` // regex the title must match
+	ExcludePattern string   `json:"excludePattern,omitempty"` // regex the title must not match
+	Groups         []string `json:"groups,omitempty"`         // release group allowlist
+	BlockedGroups  []string `json:"blockedGroups,omitempty"`  // release group blocklist
+	MinSize        int64    `json:"minSize,omitempty"`        // bytes, 0 = no limit
+	MaxSize        int64    `json:"maxSize,omitempty"`        // bytes, 0 = no limit
+	Codecs         []string `json:"codecs,omitempty"`         // allowed codecs: "HEVC", "AVC", "AV1"
+	Sources        []string `json:"sources,omitempty"`        // allowed sources: "BD", "WEB", "DVD", "TV"
+
+	// UpgradePolicy decides whether a second release of an episode already
+	// taken is grabbed: "none" (default), "revision" (v2+ from the same group)
+	// or "quality" (a better-scoring release). AutoLink links completed
+	// downloads into the library, replacing the file an upgrade supersedes.
+	UpgradePolicy string `json:"upgradePolicy"`
+	AutoLink      bool   `json:"autoLink"`
+
+	// qBittorrent options for grabbed torrents. Category defaults to the
+	// global qBittorrent category and Tags to "link-anime" plus the rule name.
+	// The download monitor also watches the categories of enabled rules, so
+	// progress, completion alerts and AutoLink work for a rule's own category.
+	Category       string   `json:"category,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	SavePath       string   `json:"savePath,omitempty"`
+	AddPaused      bool     `json:"addPaused,omitempty"`
+	Sequential     bool     `json:"sequential,omitempty"`
+	FirstLastPiece bool     `json:"firstLastPiece,omitempty"`
+
+	// ProfileID selects a quality profile for scoring, minimum score and grab delay (0 = none)
+	ProfileID int64 `json:"profileId,omitempty"`
+
+	// Completion: the rule completes and disables itself once episodes 1 to
+	// ExpectedEpisodes are all in the library, or once EndDate has passed and
+	// no new match has arrived for a grace period. Both are optional.
+	ExpectedEpisodes int        `json:"expectedEpisodes,omitempty"`
+	EndDate          *time.Time `json:"endDate,omitempty"`
+	CompletedAt      *time.Time `json:"completedAt,omitempty"`
+	CompletedReason  string     `json:"completedReason,omitempty"`
+
+	// Air schedule set by hand: weekday (0 = Sunday) and "HH:MM" in UTC. When
+	// unset, the schedule is learned from past matches. MissedAlertAt is when
+	// a missed episode was last reported.
+	AirDay        *int       `json:"airDay,omitempty"`
+	AirTime       string     `json:"airTime,omitempty"`
+	MissedAlertAt *time.Time `json:"missedAlertAt,omitempty"`
+
+	// Scheduling: PollIntervalMinutes overrides the global poll interval
+	// (0 = use it). NextCheck is when the rule is next due; failed fetches
+	// push it back exponentially, counted by ConsecutiveFailures.
+	PollIntervalMinutes int        `json:"pollIntervalMinutes,omitempty"`
+	NextCheck           *time.Time `json:"nextCheck,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures,omitempty"`
+
+	// Health: the last fetch error (cleared on success), the last successful
+	// fetch and the last grab.
+	LastError   string     `json:"lastError,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	LastMatch   *time.Time `json:"lastMatch,omitempty"`
+
 	Enabled    bool       `json:"enabled"`
 	LastCheck  *time.Time `json:"lastCheck,omitempty"`
 	CreatedAt  time.Time  `json:"createdAt"`
@@ -179,8 +434,81 @@ type RSSMatch struct {
 	ID       int64     `json:"id"`
 	RuleID   int64     `json:"ruleId"`
 	Title    string    `json:"title"`
-	Hash     string    `json:"hash"`
+	Hash     string    `json:"hash"`               // dedupe key: info hash, or title hash for legacy rows
+	InfoHash string    `json:"infoHash,omitempty"` // BitTorrent info hash, if known
 	Matched  time.Time `json:"matched"`
-	Status   string    `json:"status"`             // "downloaded", "linked", "failed"
+	Status   string    `json:"status"`             // "downloaded", "pending", "linked", "failed", "candidate", "skipped", "rejected", "replaced", "library"
 	RuleName string    `json:"ruleName,omitempty"` // populated by join queries
+
+	// Parsed episode identity, used to skip duplicates and detect upgrades
+	Season     *int   `json:"season,omitempty"`
+	Episode    *int   `json:"episode,omitempty"`
+	Resolution string `json:"resolution,omitempty"`
+	Group      string `json:"group,omitempty"`
+	Revision   int    `json:"revision"`
+	Score      int    `json:"score"`
+	UpgradeOf  *int64 `json:"upgradeOf,omitempty"` // match this one replaces
+	Reason     string `json:"reason,omitempty"`    // why it was skipped, rejected or is waiting
+	Magnet     string `json:"-"`                   // kept so delayed candidates and retries can be grabbed later
+
+	// Retry queue for pending and failed grabs
+	Attempts  int        `json:"attempts,omitempty"`
+	NextRetry *time.Time `json:"nextRetry,omitempty"`
+}
+
+// ScheduleEntry is a rule's weekly release pattern and the next episode it expects.
+type ScheduleEntry struct {
+	RuleID   int64      `json:"ruleId"`
+	RuleName string     `json:"ruleName"`
+	ShowName string     `json:"showName"`
+	Season   int        `json:"season"`
+	Episode  *int       `json:"episode,omitempty"` // next expected episode, if known
+	AirDay   int        `json:"airDay"`            // 0 = Sunday
+	AirTime  string     `json:"airTime"`           // "HH:MM" UTC
+	Source   string     `json:"source"`            // "manual" or "learned"
+	Samples  int        `json:"samples,omitempty"` // matches the learned pattern is based on
+	LastGrab *time.Time `json:"lastGrab,omitempty"`
+	NextAir  time.Time  `json:"nextAir"`
+	Missed   bool       `json:"missed"` // nothing matched within the grace period after NextAir
+}
+
+// BackfillResult summarizes what a new rule did with items already in its feed.
+type BackfillResult struct {
+	Mode      string   `json:"mode"`
+	Grabbed   []string `json:"grabbed"`   // titles sent to qBittorrent
+	Skipped   int      `json:"skipped"`   // recorded so the poller won't grab them
+	InLibrary int      `json:"inLibrary"` // episodes already in the library
+}
+
+// RSSTestItem is one feed item in a rule dry run.
+type RSSTestItem struct {
+	Title      string `json:"title"`
+	Size       string `json:"size,omitempty"`
+	Seeders    int    `json:"seeders"`
+	Result     string `json:"result"`           // "would-grab", "already-matched" or "rejected"
+	Reason     string `json:"reason,omitempty"` // why it was rejected, or a note on the grab
+	Score      int    `json:"score"`
+	Season     *int   `json:"season,omitempty"`
+	Episode    *int   `json:"episode,omitempty"`
+	Resolution string `json:"resolution,omitempty"`
+	Group      string `json:"group,omitempty"`
+}
+
+// ScoreEntry adds Score to a release whose group or codec equals Value.
+type ScoreEntry struct {
+	Value string `json:"value"`
+	Score int    `json:"score"`
+}
+
+// QualityProfile ranks competing releases of an episode.
+type QualityProfile struct {
+	ID           int64        `json:"id"`
+	Name         string       `json:"name"`
+	Resolutions  []string     `json:"resolutions"`  // best first, e.g. ["1080p", "720p"]
+	Sources      []string     `json:"sources"`      // best first, e.g. ["BD", "WEB"]
+	Groups       []ScoreEntry `json:"groups"`       // preferred (or penalized) release groups
+	Codecs       []ScoreEntry `json:"codecs"`       // preferred (or penalized) codecs
+	MinScore     int          `json:"minScore"`     // releases scoring below this are rejected
+	DelayMinutes int          `json:"delayMinutes"` // wait this long after the first release of an episode, then grab the best
+	CreatedAt    time.Time    `json:"createdAt"`
 }
@@ -20,6 +20,14 @@ type DownloadMonitor struct {
 	category   func() string
 	interval   time.Duration
 
+	// OnComplete, if set before Start, is called in its own goroutine for
+	// each newly completed torrent.
+	OnComplete func(models.TorrentStatus)
+
+	// ExtraCategories, if set before Start, returns categories watched in
+	// addition to the global one, e.g. those RSS rules send grabs to.
+	ExtraCategories func() []string
+
 	// Track previous torrent states to detect completions
 	prevStates map[string]float64
 	mu         sync.Mutex
@@ -85,8 +93,7 @@ func (m *DownloadMonitor) poll() {
 		return
 	}
 
-	cat := m.category()
-	torrents, err := client.ListTorrents(cat)
+	torrents, err := m.listTorrents(client)
 	if err != nil {
 		log.Printf("[monitor] failed to poll qBit: %v", err)
 		return
@@ -140,6 +147,10 @@ func (m *DownloadMonitor) poll() {
 				Data: t,
 			})
 
+			if m.OnComplete != nil {
+				go m.OnComplete(t)
+			}
+
 			// Send external notification
 			if n != nil {
 				n.Send(
@@ -154,3 +165,32 @@ func (m *DownloadMonitor) poll() {
 		}
 	}
 }
+
+// listTorrents returns the torrents in the watched categories. With extra
+// categories it lists everything once and filters, rather than one request each.
+func (m *DownloadMonitor) listTorrents(client *qbit.Client) ([]models.TorrentStatus, error) {
+	cat := m.category()
+	var extra []string
+	if m.ExtraCategories != nil && cat != "" {
+		extra = m.ExtraCategories()
+	}
+	if len(extra) == 0 {
+		return client.ListTorrents(cat)
+	}
+
+	watched := map[string]bool{cat: true}
+	for _, c := range extra {
+		watched[c] = true
+	}
+	all, err := client.ListTorrents("")
+	if err != nil {
+		return nil, err
+	}
+	torrents := all[:0]
+	for _, t := range all {
+		if watched[t.Category] {
+			torrents = append(torrents, t)
+		}
+	}
+	return torrents, nil
+}
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"link-anime/internal/httpx"
 )
 
 // Notifier sends notifications to configured endpoints.
@@ -55,8 +57,7 @@ func (n *Notifier) sendNtfy(title, message string, fields []Field) {
 	}
 	req.Header.Set("Title", title)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	client.Do(req) //nolint:errcheck
+	send(req)
 }
 
 func (n *Notifier) sendDiscord(title, message string, fields []Field, color string) {
@@ -116,8 +117,7 @@ func (n *Notifier) sendDiscord(title, message string, fields []Field, color stri
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	client.Do(req) //nolint:errcheck
+	send(req)
 }
 
 func (n *Notifier) sendGeneric(title, message string) {
@@ -137,8 +137,16 @@ func (n *Notifier) sendGeneric(title, message string) {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	client.Do(req) //nolint:errcheck
+	send(req)
+}
+
+var client = httpx.New(httpx.Options{Name: "notify", Timeout: 10 * time.Second})
+
+// send posts a notification; failures only show up in the HTTP metrics.
+func send(req *http.Request) {
+	if resp, err := client.Do(req); err == nil {
+		resp.Body.Close()
+	}
 }
 
 // FormatSize converts bytes to a human-readable string.
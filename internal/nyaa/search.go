@@ -3,55 +3,288 @@ package nyaa
 import (
 	"encoding/xml"
 	"fmt"
-	"net/http"
 	"net/url"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"link-anime/internal/httpx"
 	"link-anime/internal/models"
 )
 
-const (
-	nyaaBaseURL = "https://nyaa.si"
-	rssPath     = "/?page=rss"
-)
+const rssPath = "/?page=rss"
+
+// baseURL is where Nyaa is reached. Tests and mirrors can change it.
+var baseURL = "https://nyaa.si"
+
+// client fetches feed pages; pages are revalidated so unchanged ones are cheap.
+var client = httpx.New(httpx.Options{Name: "nyaa", Timeout: 15 * time.Second, RateLimit: true, Cache: true, Proxy: true})
+
+// SetBaseURL points searches and torrent downloads at a Nyaa mirror.
+func SetBaseURL(u string) {
+	if u != "" {
+		baseURL = strings.TrimRight(u, "/")
+	}
+}
 
 // rssItem represents a single item in the Nyaa RSS feed.
 type rssItem struct {
-	Title    string `xml:"title"`
-	Link     string `xml:"link"`
-	GUID     string `xml:"guid"`
-	Seeders  string `xml:"https://nyaa.si/xmlns/nyaa seeders"`
-	Leechers string `xml:"https://nyaa.si/xmlns/nyaa leechers"`
-	Size     string `xml:"https://nyaa.si/xmlns/nyaa size"`
+	Title        string `xml:"title"`
+	Link         string `xml:"link"`
+	GUID         string `xml:"guid"`
+	PubDate      string `xml:"pubDate"`
+	Seeders      string `xml:"https://nyaa.si/xmlns/nyaa seeders"`
+	Leechers     string `xml:"https://nyaa.si/xmlns/nyaa leechers"`
+	Downloads    string `xml:"https://nyaa.si/xmlns/nyaa downloads"`
+	Size         string `xml:"https://nyaa.si/xmlns/nyaa size"`
+	InfoHash     string `xml:"https://nyaa.si/xmlns/nyaa infoHash"`
+	CategoryID   string `xml:"https://nyaa.si/xmlns/nyaa categoryId"`
+	CategoryName string `xml:"https://nyaa.si/xmlns/nyaa category"`
+	Trusted      string `xml:"https://nyaa.si/xmlns/nyaa trusted"`
+	Remake       string `xml:"https://nyaa.si/xmlns/nyaa remake"`
 }
 
 type rssChannel struct {
 	Items []rssItem `xml:"channel>item"`
 }
 
-var magnetRe = regexp.MustCompile(`magnet:\?xt=urn:btih:[a-fA-F0-9]{40}`)
+// trackers are added to magnets so peers can be found without DHT.
+// These are the trackers Nyaa embeds in its own magnet links.
+var trackers = []string{
+	"http://nyaa.tracker.wf:7777/announce",
+	"udp://open.stealth.si:80/announce",
+	"udp://tracker.opentrackr.org:1337/announce",
+	"udp://exodus.desync.com:6969/announce",
+	"udp://tracker.torrent.eu.org:451/announce",
+}
+
+// Magnet builds a magnet URI from an info hash, with display name and trackers.
+func Magnet(infoHash, title string) string {
+	params := url.Values{}
+	if title != "" {
+		params.Set("dn", title)
+	}
+	for _, tr := range trackers {
+		params.Add("tr", tr)
+	}
+	return "magnet:?xt=urn:btih:" + strings.ToLower(infoHash) + "&" + params.Encode()
+}
+
+// toResult converts a feed item, building a magnet when the info hash is known
+// and falling back to the .torrent link otherwise.
+func (item rssItem) toResult() models.NyaaResult {
+	seeders, _ := strconv.Atoi(item.Seeders)
+	leechers, _ := strconv.Atoi(item.Leechers)
+	downloads, _ := strconv.Atoi(item.Downloads)
+
+	infoHash := strings.ToLower(strings.TrimSpace(item.InfoHash))
+	magnet := item.Link
+	if infoHash != "" {
+		magnet = Magnet(infoHash, item.Title)
+	}
+
+	res := models.NyaaResult{
+		Title:        item.Title,
+		Magnet:       magnet,
+		TorrentURL:   item.Link,
+		InfoHash:     infoHash,
+		Size:         item.Size,
+		SizeBytes:    ParseSize(item.Size),
+		Seeders:      seeders,
+		Leechers:     leechers,
+		ViewURL:      item.GUID,
+		Category:     item.CategoryID,
+		CategoryName: item.CategoryName,
+		Downloads:    downloads,
+		Trusted:      strings.EqualFold(item.Trusted, "Yes"),
+		Remake:       strings.EqualFold(item.Remake, "Yes"),
+	}
+	if t, err := time.Parse(time.RFC1123Z, strings.TrimSpace(item.PubDate)); err == nil {
+		res.Date = &t
+	}
+	return res
+}
+
+// DefaultCategory is Nyaa's "Anime - English-translated" category.
+const DefaultCategory = "1_2"
+
+// Categories are the Nyaa categories searches may use, by ID.
+var Categories = map[string]string{
+	"0_0": "All categories",
+	"1_0": "Anime",
+	"1_1": "Anime - Anime Music Video",
+	"1_2": "Anime - English-translated",
+	"1_3": "Anime - Non-English-translated",
+	"1_4": "Anime - Raw",
+	"2_0": "Audio",
+	"2_1": "Audio - Lossless",
+	"2_2": "Audio - Lossy",
+	"3_0": "Literature",
+	"3_1": "Literature - English-translated",
+	"3_2": "Literature - Non-English-translated",
+	"3_3": "Literature - Raw",
+	"4_0": "Live Action",
+	"4_1": "Live Action - English-translated",
+	"4_2": "Live Action - Idol/Promotional Video",
+	"4_3": "Live Action - Non-English-translated",
+	"4_4": "Live Action - Raw",
+	"5_0": "Pictures",
+	"5_1": "Pictures - Graphics",
+	"5_2": "Pictures - Photos",
+	"6_0": "Software",
+	"6_1": "Software - Applications",
+	"6_2": "Software - Games",
+}
+
+// Sort orders, as Nyaa names them.
+var sortFields = map[string]string{
+	"date":      "id",
+	"id":        "id",
+	"seeders":   "seeders",
+	"leechers":  "leechers",
+	"downloads": "downloads",
+	"size":      "size",
+	"comments":  "comments",
+}
+
+// MaxPages caps how many feed pages one search may fetch.
+const MaxPages = 5
+
+// SearchOptions are the parameters of a Nyaa search. Zero values mean the
+// defaults: English-translated anime, no filter, newest first, one page.
+type SearchOptions struct {
+	Query    string
+	Category string // see Categories
+	Filter   string // "trusted", "noremakes" or "" for all
+	User     string // restrict to one submitter
+	Sort     string // "date", "seeders", "leechers", "downloads", "size" or "comments"
+	Order    string // "desc" (default) or "asc"
+	Pages    int    // 1 to MaxPages
+	Site     string // Nyaa mirror to search; empty = the configured site
+}
 
 // Search queries Nyaa's RSS feed for anime torrents.
 func Search(query string, filter string) ([]models.NyaaResult, error) {
+	return SearchWith(SearchOptions{Query: query, Filter: filter})
+}
+
+// SearchFeed queries Nyaa's RSS feed with an explicit category (e.g. "1_2",
+// "0_0" for all) and optionally restricts results to one uploader's feed.
+func SearchFeed(query, category, filter, user string) ([]models.NyaaResult, error) {
+	return SearchWith(SearchOptions{Query: query, Category: category, Filter: filter, User: user})
+}
+
+// SearchWith runs a search with full options. Pages are fetched in turn until
+// one comes back short or repeats what was already seen.
+func SearchWith(opts SearchOptions) ([]models.NyaaResult, error) {
+	params, err := opts.params()
+	if err != nil {
+		return nil, err
+	}
+	pages := opts.Pages
+	if pages < 1 {
+		pages = 1
+	}
+	if pages > MaxPages {
+		pages = MaxPages
+	}
+
+	var results []models.NyaaResult
+	seen := map[string]bool{}
+	for page := 1; page <= pages; page++ {
+		if page > 1 {
+			params.Set("p", strconv.Itoa(page))
+		}
+		items, err := fetchPage(opts.Site, params)
+		if err != nil {
+			return nil, err
+		}
+
+		added := 0
+		for _, item := range items {
+			res := item.toResult()
+			key := res.InfoHash
+			if key == "" {
+				key = res.TorrentURL
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			results = append(results, res)
+			added++
+		}
+		if added == 0 || len(items) < feedPageSize {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// feedPageSize is how many items Nyaa puts on a full RSS page.
+const feedPageSize = 75
+
+// Validate checks the options without searching.
+func (opts SearchOptions) Validate() error {
+	_, err := opts.params()
+	return err
+}
+
+// params builds the feed query string, validating the options.
+func (opts SearchOptions) params() (url.Values, error) {
+	category := opts.Category
+	if category == "" {
+		category = DefaultCategory
+	}
+	if _, ok := Categories[category]; !ok {
+		return nil, fmt.Errorf("unknown nyaa category %q", category)
+	}
+
 	params := url.Values{
 		"page": {"rss"},
-		"q":    {query},
-		"c":    {"1_2"}, // Anime - English-translated
-		"f":    {"0"},   // No filter
+		"q":    {opts.Query},
+		"c":    {category},
+		"f":    {"0"}, // No filter
 	}
 
-	if filter == "trusted" {
+	switch opts.Filter {
+	case "trusted":
 		params.Set("f", "2")
-	} else if filter == "noremakes" {
+	case "noremakes":
 		params.Set("f", "1")
+	case "", "all":
+	default:
+		return nil, fmt.Errorf("unknown nyaa filter %q", opts.Filter)
 	}
+	if opts.User != "" {
+		params.Set("u", opts.User)
+	}
+
+	if opts.Sort != "" {
+		field, ok := sortFields[opts.Sort]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort %q", opts.Sort)
+		}
+		params.Set("s", field)
+	}
+	switch opts.Order {
+	case "":
+	case "asc", "desc":
+		params.Set("o", opts.Order)
+	default:
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+
+	return params, nil
+}
 
-	reqURL := nyaaBaseURL + "/?" + params.Encode()
+func fetchPage(site string, params url.Values) ([]rssItem, error) {
+	if site == "" {
+		site = baseURL
+	}
+	reqURL := strings.TrimRight(site, "/") + "/?" + params.Encode()
 
-	client := &http.Client{Timeout: 15 * time.Second}
 	resp, err := client.Get(reqURL)
 	if err != nil {
 		return nil, fmt.Errorf("nyaa search: %w", err)
@@ -66,35 +299,33 @@ func Search(query string, filter string) ([]models.NyaaResult, error) {
 	if err := xml.NewDecoder(resp.Body).Decode(&rss); err != nil {
 		return nil, fmt.Errorf("nyaa parse: %w", err)
 	}
+	return rss.Items, nil
+}
 
-	var results []models.NyaaResult
-	for _, item := range rss.Items {
-		seeders, _ := strconv.Atoi(item.Seeders)
-		leechers, _ := strconv.Atoi(item.Leechers)
-
-		// Extract magnet from link or GUID (Nyaa provides torrent links, not magnets in RSS)
-		// We'll provide the download link; the handler can fetch magnet if needed
-		magnet := item.Link
-		if strings.HasPrefix(item.GUID, "https://nyaa.si/view/") {
-			// We'll construct a magnet-fetch URL; actual magnet requires scraping the page
-			// For now, use the torrent download link
-			magnet = item.Link
-		}
-
-		results = append(results, models.NyaaResult{
-			Title:    item.Title,
-			Magnet:   magnet,
-			Size:     item.Size,
-			Seeders:  seeders,
-			Leechers: leechers,
-		})
+// ParseSize converts a Nyaa size string like "1.4 GiB" or "350.2 MiB" to bytes.
+// Returns 0 if the string can't be parsed.
+func ParseSize(s string) int64 {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0
 	}
-
-	return results, nil
+	n, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+	units := map[string]float64{
+		"b": 1, "kib": 1 << 10, "mib": 1 << 20, "gib": 1 << 30, "tib": 1 << 40,
+		"kb": 1e3, "mb": 1e6, "gb": 1e9, "tb": 1e12,
+	}
+	mult, ok := units[strings.ToLower(fields[1])]
+	if !ok {
+		return 0
+	}
+	return int64(n * mult)
 }
 
-// SearchWithMagnets is like Search but also fetches magnet links from each result page.
-// This is slower but provides actual magnet URIs.
+// SearchWithMagnets is like Search but limits the number of results.
+// Magnets are built from the feed's info hashes, so no page scraping is needed.
 func SearchWithMagnets(query string, filter string, limit int) ([]models.NyaaResult, error) {
 	results, err := Search(query, filter)
 	if err != nil {
@@ -105,63 +336,5 @@ func SearchWithMagnets(query string, filter string, limit int) ([]models.NyaaRes
 		results = results[:limit]
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	for i := range results {
-		// The Link field from RSS is the .torrent download URL
-		// We need to get the page URL and scrape the magnet from it
-		pageURL := results[i].Magnet
-		if !strings.HasPrefix(pageURL, "https://nyaa.si/view/") {
-			// Try to extract the view URL from the download link
-			// Download links look like: https://nyaa.si/download/1234567.torrent
-			pageURL = strings.Replace(pageURL, "/download/", "/view/", 1)
-			pageURL = strings.TrimSuffix(pageURL, ".torrent")
-		}
-
-		resp, err := client.Get(pageURL)
-		if err != nil {
-			continue
-		}
-
-		body := make([]byte, 64*1024) // Read up to 64KB
-		n, _ := resp.Body.Read(body)
-		resp.Body.Close()
-
-		if m := magnetRe.Find(body[:n]); m != nil {
-			results[i].Magnet = string(m)
-		}
-	}
-
-	return results, nil
-}
-
-// FetchRSSFeed fetches a custom Nyaa RSS URL and returns parsed results.
-func FetchRSSFeed(rssURL string) ([]models.NyaaResult, error) {
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Get(rssURL)
-	if err != nil {
-		return nil, fmt.Errorf("fetch rss: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var rss rssChannel
-	if err := xml.NewDecoder(resp.Body).Decode(&rss); err != nil {
-		return nil, fmt.Errorf("parse rss: %w", err)
-	}
-
-	var results []models.NyaaResult
-	for _, item := range rss.Items {
-		seeders, _ := strconv.Atoi(item.Seeders)
-		leechers, _ := strconv.Atoi(item.Leechers)
-
-		results = append(results, models.NyaaResult{
-			Title:    item.Title,
-			Magnet:   item.Link,
-			Size:     item.Size,
-			Seeders:  seeders,
-			Leechers: leechers,
-		})
-	}
-
 	return results, nil
 }
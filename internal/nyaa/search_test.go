@@ -0,0 +1,83 @@
+package nyaa
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+const sampleFeed = `<?xml version="1.0" encoding="utf-8"?>
+<rss xmlns:atom="http://www.w3.org/2005/Atom" xmlns:nyaa="https://nyaa.si/xmlns/nyaa" version="2.0">
+<channel>
+<item>
+	<title>[SubsPlease] Frieren - 05 (1080p) [ABCD1234].mkv</title>
+	<link>https://nyaa.si/download/1234567.torrent</link>
+	<guid isPermaLink="true">https://nyaa.si/view/1234567</guid>
+	<pubDate>Fri, 06 Oct 2023 16:00:00 -0000</pubDate>
+	<nyaa:seeders>1500</nyaa:seeders>
+	<nyaa:leechers>40</nyaa:leechers>
+	<nyaa:downloads>25000</nyaa:downloads>
+	<nyaa:infoHash>0123456789ABCDEF0123456789ABCDEF01234567</nyaa:infoHash>
+	<nyaa:categoryId>1_2</nyaa:categoryId>
+	<nyaa:category>Anime - English-translated</nyaa:category>
+	<nyaa:size>1.4 GiB</nyaa:size>
+	<nyaa:trusted>Yes</nyaa:trusted>
+	<nyaa:remake>No</nyaa:remake>
+</item>
+</channel>
+</rss>`
+
+func TestItemToResult(t *testing.T) {
+	var feed rssChannel
+	if err := xml.NewDecoder(strings.NewReader(sampleFeed)).Decode(&feed); err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Items) != 1 {
+		t.Fatalf("got %d items, want 1", len(feed.Items))
+	}
+	res := feed.Items[0].toResult()
+
+	if res.InfoHash != "0123456789abcdef0123456789abcdef01234567" {
+		t.Errorf("InfoHash = %q", res.InfoHash)
+	}
+	if !strings.HasPrefix(res.Magnet, "magnet:?xt=urn:btih:0123456789abcdef") {
+		t.Errorf("Magnet = %q", res.Magnet)
+	}
+	if res.Category != "1_2" || res.CategoryName != "Anime - English-translated" {
+		t.Errorf("Category = %q / %q", res.Category, res.CategoryName)
+	}
+	if res.Seeders != 1500 || res.Leechers != 40 || res.Downloads != 25000 {
+		t.Errorf("counts = %d/%d/%d", res.Seeders, res.Leechers, res.Downloads)
+	}
+	if !res.Trusted || res.Remake {
+		t.Errorf("Trusted = %v, Remake = %v", res.Trusted, res.Remake)
+	}
+	if res.Date == nil || res.Date.Year() != 2023 || res.Date.Day() != 6 {
+		t.Errorf("Date = %v", res.Date)
+	}
+	if res.ViewURL != "https://nyaa.si/view/1234567" {
+		t.Errorf("ViewURL = %q", res.ViewURL)
+	}
+}
+
+func TestSearchOptionsParams(t *testing.T) {
+	params, err := SearchOptions{Query: "frieren", Category: "1_4", Filter: "trusted", User: "subsplease", Sort: "seeders", Order: "desc"}.params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"q": "frieren", "c": "1_4", "f": "2", "u": "subsplease", "s": "seeders", "o": "desc"}
+	for k, v := range want {
+		if got := params.Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+
+	if got := (SearchOptions{Sort: "date"}); got.Validate() != nil {
+		t.Errorf("sort by date rejected")
+	}
+	for _, bad := range []SearchOptions{{Category: "9_9"}, {Filter: "x"}, {Sort: "name"}, {Order: "up"}} {
+		if bad.Validate() == nil {
+			t.Errorf("%+v accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package nyaa
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"link-anime/internal/httpx"
+)
+
+// maxTorrentSize bounds .torrent downloads; large batches are still well under it.
+const maxTorrentSize = 10 << 20
+
+var torrentClient = httpx.New(httpx.Options{Name: "nyaa", Timeout: 15 * time.Second, RateLimit: true, Proxy: true})
+
+var (
+	reTorrentID = regexp.MustCompile(`^/(?:view|download)/(\d+)(?:\.torrent)?/?$`)
+	reInfoHash  = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+	reNumericID = regexp.MustCompile(`^\d+$`)
+)
+
+// TorrentID resolves a reference to a Nyaa torrent ID. ref may be the ID
+// itself, a view or download URL on the configured Nyaa site, or an info
+// hash, which is looked up with a search.
+func TorrentID(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	switch {
+	case ref == "":
+		return "", fmt.Errorf("torrent reference is empty")
+	case reNumericID.MatchString(ref):
+		return ref, nil
+	case reInfoHash.MatchString(ref):
+		return idForInfoHash(strings.ToLower(ref))
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("not a Nyaa ID, URL or info hash: %q", ref)
+	}
+	base, _ := url.Parse(baseURL)
+	if !strings.EqualFold(u.Host, base.Host) && !strings.EqualFold(u.Host, "nyaa.si") {
+		return "", fmt.Errorf("%s is not a Nyaa URL", u.Host)
+	}
+	m := reTorrentID.FindStringSubmatch(u.Path)
+	if m == nil {
+		return "", fmt.Errorf("not a Nyaa torrent URL: %q", ref)
+	}
+	return m[1], nil
+}
+
+// idForInfoHash finds the torrent with the given info hash across all categories.
+func idForInfoHash(hash string) (string, error) {
+	results, err := SearchWith(SearchOptions{Query: hash, Category: "0_0"})
+	if err != nil {
+		return "", err
+	}
+	for _, res := range results {
+		if res.InfoHash != hash {
+			continue
+		}
+		for _, link := range []string{res.ViewURL, res.TorrentURL} {
+			if u, err := url.Parse(link); err == nil {
+				if m := reTorrentID.FindStringSubmatch(u.Path); m != nil {
+					return m[1], nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("no Nyaa torrent with info hash %s", hash)
+}
+
+// FetchTorrent downloads the .torrent file of a Nyaa torrent. See TorrentID
+// for what ref may be.
+func FetchTorrent(ref string) ([]byte, error) {
+	id, err := TorrentID(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := torrentClient.Get(baseURL + "/download/" + id + ".torrent")
+	if err != nil {
+		return nil, fmt.Errorf("nyaa torrent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("nyaa torrent %s not found", id)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("nyaa returned status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTorrentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("nyaa torrent: %w", err)
+	}
+	if len(data) > maxTorrentSize {
+		return nil, fmt.Errorf("nyaa torrent %s is larger than %d MiB", id, maxTorrentSize>>20)
+	}
+	return data, nil
+}
@@ -0,0 +1,62 @@
+package nyaa
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeNyaa serves sampleFeed for searches and one .torrent download.
+func fakeNyaa(t *testing.T) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("c") != "0_0" {
+			t.Errorf("info hash lookup searched category %q", r.URL.Query().Get("c"))
+		}
+		w.Write([]byte(sampleFeed))
+	})
+	mux.HandleFunc("/download/1234567.torrent", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("d4:infod4:name4:showee"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	old := baseURL
+	SetBaseURL(srv.URL)
+	t.Cleanup(func() { baseURL = old })
+}
+
+func TestFetchTorrent(t *testing.T) {
+	fakeNyaa(t)
+
+	for _, ref := range []string{
+		"1234567",
+		baseURL + "/view/1234567",
+		"https://nyaa.si/download/1234567.torrent",
+		"0123456789ABCDEF0123456789ABCDEF01234567",
+	} {
+		data, err := FetchTorrent(ref)
+		if err != nil {
+			t.Errorf("FetchTorrent(%q): %v", ref, err)
+			continue
+		}
+		if string(data) != "d4:infod4:name4:showee" {
+			t.Errorf("FetchTorrent(%q) = %q", ref, data)
+		}
+	}
+
+	if _, err := FetchTorrent("7654321"); err == nil {
+		t.Error("missing torrent was fetched")
+	}
+	if _, err := FetchTorrent("ffffffffffffffffffffffffffffffffffffffff"); err == nil {
+		t.Error("unknown info hash was resolved")
+	}
+	if _, err := FetchTorrent("https://example.com/download/1.torrent"); err == nil {
+		t.Error("non-Nyaa URL was accepted")
+	}
+}
@@ -211,3 +211,102 @@ func TestParseReleaseName(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRelease(t *testing.T) {
+	tests := []struct {
+		input      string
+		group      string
+		title      string
+		season     *int
+		episode    *int
+		batch      bool
+		resolution string
+		codec      string
+		source     string
+		revision   int
+	}{
+		// Weekly SubsPlease release
+		{
+			"[SubsPlease] Sousou no Frieren - 05 (1080p) [ABCD1234].mkv",
+			"SubsPlease", "Sousou no Frieren", nil, intPtr(5), false, "1080p", "", "", 1,
+		},
+		// Revision and season keyword before the episode
+		{
+			"[Erai-raws] Kusuriya no Hitorigoto 2nd Season - 13v2 [1080p][HEVC]",
+			"Erai-raws", "Kusuriya no Hitorigoto", intPtr(2), intPtr(13), false, "1080p", "HEVC", "", 2,
+		},
+		// Dot-style SxxExx scene release
+		{
+			"Frieren.Beyond.Journeys.End.S02E05.Logistics.in.the.Northern.Plateau.1080p.NF.WEB-DL.JPN.AAC2.0.H.264.MSubs-ToonsHub.mkv",
+			"", "Frieren Beyond Journeys End", intPtr(2), intPtr(5), false, "1080p", "AVC", "WEB", 1,
+		},
+		// Batch with episode range
+		{
+			"[Erai-raws] Sousou no Frieren S01 (01-28) [1080p][Multiple Subtitle] [Batch]",
+			"Erai-raws", "Sousou no Frieren", intPtr(1), intPtr(1), true, "1080p", "", "", 1,
+		},
+		// BD season pack without episode numbers
+		{
+			"[Breeze] Dr. STONE - New World - S03 v3 [1080p BD AV1][Dual Audio]",
+			"Breeze", "Dr. STONE - New World", intPtr(3), nil, false, "1080p", "AV1", "BD", 3,
+		},
+		// HEVC re-encode
+		{
+			"[ASW] Dandadan - 01 [1080p HEVC x265 10Bit][AAC]",
+			"ASW", "Dandadan", nil, intPtr(1), false, "1080p", "HEVC", "", 1,
+		},
+		// Range with dash style
+		{
+			"[Judas] Oshi no Ko - 01-11 [BD 2160p]",
+			"Judas", "Oshi no Ko", nil, intPtr(1), true, "2160p", "", "BD", 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			rel := ParseRelease(tt.input)
+
+			if rel.Group != tt.group {
+				t.Errorf("Group: got %q, want %q", rel.Group, tt.group)
+			}
+			if rel.Title != tt.title {
+				t.Errorf("Title: got %q, want %q", rel.Title, tt.title)
+			}
+			if !equalIntPtr(rel.Season, tt.season) {
+				t.Errorf("Season: got %v, want %v", fmtIntPtr(rel.Season), fmtIntPtr(tt.season))
+			}
+			if !equalIntPtr(rel.Episode, tt.episode) {
+				t.Errorf("Episode: got %v, want %v", fmtIntPtr(rel.Episode), fmtIntPtr(tt.episode))
+			}
+			if rel.Batch != tt.batch {
+				t.Errorf("Batch: got %v, want %v", rel.Batch, tt.batch)
+			}
+			if rel.Resolution != tt.resolution {
+				t.Errorf("Resolution: got %q, want %q", rel.Resolution, tt.resolution)
+			}
+			if rel.Codec != tt.codec {
+				t.Errorf("Codec: got %q, want %q", rel.Codec, tt.codec)
+			}
+			if rel.Source != tt.source {
+				t.Errorf("Source: got %q, want %q", rel.Source, tt.source)
+			}
+			if rel.Revision != tt.revision {
+				t.Errorf("Revision: got %d, want %d", rel.Revision, tt.revision)
+			}
+		})
+	}
+}
+
+func equalIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func fmtIntPtr(p *int) interface{} {
+	if p == nil {
+		return "nil"
+	}
+	return *p
+}
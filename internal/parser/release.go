@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Release holds the structured fields of a torrent release title.
+type Release struct {
+	Group      string // leading [Group] tag
+	Title      string // clean show name, as from ParseReleaseName
+	Season     *int
+	Episode    *int
+	EpisodeEnd *int   // last episode of a range, e.g. 12 in "01-12"
+	Batch      bool   // episode range or batch/complete keyword
+	Resolution string // "2160p", "1080p", "720p", ...
+	Codec      string // "HEVC", "AVC", "AV1"
+	Source     string // "BD", "WEB", "DVD", "TV"
+	Revision   int    // 1 unless tagged v2, v3, ...
+}
+
+var (
+	// S01E05, S01E01-E12, S01E05v2
+	reRelSxxEyy = regexp.MustCompile(`(?i)\bS(\d{1,2})\s*E(\d{1,4})(?:\s*-\s*E?(\d{1,4}))?`)
+
+	// SubsPlease/Erai style " - 05", " - 05v2", " - 01-12"
+	reRelDashEp = regexp.MustCompile(`\s-\s(\d{1,4})(?:\s*-\s*(\d{1,4}))?(?:v\d+)?(?:\s|$|[\[\(.])`)
+
+	// E05, EP05, Episode 5, EP01-EP11
+	reRelEp = regexp.MustCompile(`(?i)\b(?:EP?|Episode\s?)(\d{1,4})(?:\s*-\s*(?:EP?)?(\d{1,4}))?(?:v\d+)?\b`)
+
+	// (01-24)
+	reRelParenRange = regexp.MustCompile(`\((\d{1,4})\s*-\s*(\d{1,4})\)`)
+
+	reRelBatch    = regexp.MustCompile(`(?i)\b(?:batch|complete)\b`)
+	reRelRevision = regexp.MustCompile(`(?i)(?:\d|\s)v(\d{1,2})\b`)
+
+	reRelResolution = regexp.MustCompile(`(?i)\b(2160|1080|720|576|480|360)[pi]\b`)
+	reRelDimensions = regexp.MustCompile(`\b\d{3,4}x(2160|1080|720|576|480)\b`)
+	reRel4K         = regexp.MustCompile(`(?i)\b(?:4K|UHD)\b`)
+
+	reRelHEVC = regexp.MustCompile(`(?i)\b(?:x265|h\.?265|hevc)\b`)
+	reRelAVC  = regexp.MustCompile(`(?i)\b(?:x264|h\.?264|avc)\b`)
+	reRelAV1  = regexp.MustCompile(`(?i)\bAV1\b`)
+
+	reRelBD  = regexp.MustCompile(`(?i)\b(?:BD|BDRip|BluRay|Blu-Ray|BDMV|BDRemux)\b`)
+	reRelWEB = regexp.MustCompile(`(?i)\b(?:WEB|WEB-?DL|WEB-?Rip|CR|AMZN|NF|DSNP|HIDIVE|ADN)\b`)
+	reRelDVD = regexp.MustCompile(`(?i)\bDVD(?:Rip)?\b`)
+	reRelTV  = regexp.MustCompile(`(?i)\b(?:HDTV|TV)\b`)
+)
+
+// ParseRelease extracts structured fields from a release title such as
+// "[SubsPlease] Sousou no Frieren - 05 (1080p) [ABCD1234].mkv".
+func ParseRelease(input string) Release {
+	rel := Release{Revision: 1}
+
+	if m := reGroupTag.FindStringSubmatch(input); m != nil {
+		rel.Group = strings.TrimSpace(m[1])
+	}
+
+	// Episode detection. For " - 05" style, the name is everything before
+	// the episode token, so cut there before cleaning up the title.
+	nameSource := input
+	if m := reRelSxxEyy.FindStringSubmatch(input); m != nil {
+		rel.Episode = atoiPtr(m[2])
+		rel.EpisodeEnd = atoiPtr(m[3])
+	} else if loc := reRelDashEp.FindStringSubmatchIndex(input); loc != nil {
+		rel.Episode = atoiPtr(input[loc[2]:loc[3]])
+		if loc[4] >= 0 {
+			rel.EpisodeEnd = atoiPtr(input[loc[4]:loc[5]])
+		}
+		nameSource = input[:loc[0]]
+	} else if m := reRelEp.FindStringSubmatch(input); m != nil {
+		rel.Episode = atoiPtr(m[1])
+		rel.EpisodeEnd = atoiPtr(m[2])
+	} else if m := reRelParenRange.FindStringSubmatch(input); m != nil {
+		rel.Episode = atoiPtr(m[1])
+		rel.EpisodeEnd = atoiPtr(m[2])
+	}
+
+	parsed := ParseReleaseName(nameSource)
+	rel.Title = parsed.Name
+	rel.Season = parsed.Season
+	if rel.Season == nil && nameSource != input {
+		rel.Season = ParseReleaseName(input).Season
+	}
+
+	rel.Batch = rel.EpisodeEnd != nil || reRelBatch.MatchString(input)
+
+	if m := reRelRevision.FindStringSubmatch(input); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > 1 {
+			rel.Revision = n
+		}
+	}
+
+	switch {
+	case reRelResolution.MatchString(input):
+		rel.Resolution = reRelResolution.FindStringSubmatch(input)[1] + "p"
+	case reRelDimensions.MatchString(input):
+		rel.Resolution = reRelDimensions.FindStringSubmatch(input)[1] + "p"
+	case reRel4K.MatchString(input):
+		rel.Resolution = "2160p"
+	}
+
+	switch {
+	case reRelHEVC.MatchString(input):
+		rel.Codec = "HEVC"
+	case reRelAV1.MatchString(input):
+		rel.Codec = "AV1"
+	case reRelAVC.MatchString(input):
+		rel.Codec = "AVC"
+	}
+
+	switch {
+	case reRelBD.MatchString(input):
+		rel.Source = "BD"
+	case reRelWEB.MatchString(input):
+		rel.Source = "WEB"
+	case reRelDVD.MatchString(input):
+		rel.Source = "DVD"
+	case reRelTV.MatchString(input):
+		rel.Source = "TV"
+	}
+
+	return rel
+}
+
+// ResolutionRank orders resolutions from worst to best; unknown is 0.
+func ResolutionRank(res string) int {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(res), "p"))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func atoiPtr(s string) *int {
+	if s == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
@@ -71,6 +71,43 @@ func (c *Client) ensureLoggedIn() error {
 	return nil
 }
 
+// rawTorrent is a torrent as returned by /api/v2/torrents/info.
+type rawTorrent struct {
+	Name         string  `json:"name"`
+	Hash         string  `json:"hash"`
+	State        string  `json:"state"`
+	Progress     float64 `json:"progress"`
+	DLSpeed      int64   `json:"dlspeed"`
+	ULSpeed      int64   `json:"upspeed"`
+	Size         int64   `json:"size"`
+	ETA          int     `json:"eta"`
+	Ratio        float64 `json:"ratio"`
+	Category     string  `json:"category"`
+	SavePath     string  `json:"save_path"`
+	ContentPath  string  `json:"content_path"`
+	SeedingTime  int64   `json:"seeding_time"`
+	CompletionOn int64   `json:"completion_on"`
+}
+
+func (t rawTorrent) toStatus() models.TorrentStatus {
+	return models.TorrentStatus{
+		Name:         t.Name,
+		Hash:         t.Hash,
+		State:        t.State,
+		Progress:     t.Progress,
+		DLSpeed:      t.DLSpeed,
+		ULSpeed:      t.ULSpeed,
+		Size:         t.Size,
+		ETA:          t.ETA,
+		Ratio:        t.Ratio,
+		Category:     t.Category,
+		SavePath:     t.SavePath,
+		ContentPath:  t.ContentPath,
+		SeedingTime:  t.SeedingTime,
+		CompletionOn: t.CompletionOn,
+	}
+}
+
 // ListTorrents returns torrents, optionally filtered by category.
 func (c *Client) ListTorrents(category string) ([]models.TorrentStatus, error) {
 	if err := c.ensureLoggedIn(); err != nil {
@@ -99,17 +136,7 @@ func (c *Client) ListTorrents(category string) ([]models.TorrentStatus, error) {
 		return c.ListTorrents(category)
 	}
 
-	var raw []struct {
-		Name     string  `json:"name"`
-		Hash     string  `json:"hash"`
-		State    string  `json:"state"`
-		Progress float64 `json:"progress"`
-		DLSpeed  int64   `json:"dlspeed"`
-		ULSpeed  int64   `json:"upspeed"`
-		Size     int64   `json:"size"`
-		ETA      int     `json:"eta"`
-		Ratio    float64 `json:"ratio"`
-	}
+	var raw []rawTorrent
 
 	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
 		return nil, fmt.Errorf("qbit decode: %w", err)
@@ -117,24 +144,29 @@ func (c *Client) ListTorrents(category string) ([]models.TorrentStatus, error) {
 
 	torrents := make([]models.TorrentStatus, len(raw))
 	for i, t := range raw {
-		torrents[i] = models.TorrentStatus{
-			Name:     t.Name,
-			Hash:     t.Hash,
-			State:    t.State,
-			Progress: t.Progress,
-			DLSpeed:  t.DLSpeed,
-			ULSpeed:  t.ULSpeed,
-			Size:     t.Size,
-			ETA:      t.ETA,
-			Ratio:    t.Ratio,
-		}
+		torrents[i] = t.toStatus()
 	}
 
 	return torrents, nil
 }
 
+// AddOptions are the optional settings for a torrent being added.
+type AddOptions struct {
+	Category       string
+	Tags           []string
+	SavePath       string
+	Paused         bool
+	Sequential     bool
+	FirstLastPiece bool // download first and last pieces first
+}
+
 // AddMagnet adds a magnet link to qBittorrent.
 func (c *Client) AddMagnet(magnet, category, savePath string) error {
+	return c.AddTorrent(magnet, AddOptions{Category: category, SavePath: savePath})
+}
+
+// AddTorrent adds a magnet or torrent URL to qBittorrent with the given options.
+func (c *Client) AddTorrent(magnet string, opts AddOptions) error {
 	if err := c.ensureLoggedIn(); err != nil {
 		return err
 	}
@@ -142,11 +174,25 @@ func (c *Client) AddMagnet(magnet, category, savePath string) error {
 	data := url.Values{
 		"urls": {magnet},
 	}
-	if category != "" {
-		data.Set("category", category)
+	if opts.Category != "" {
+		data.Set("category", opts.Category)
 	}
-	if savePath != "" {
-		data.Set("savepath", savePath)
+	if len(opts.Tags) > 0 {
+		data.Set("tags", strings.Join(opts.Tags, ","))
+	}
+	if opts.SavePath != "" {
+		data.Set("savepath", opts.SavePath)
+	}
+	if opts.Paused {
+		// qBittorrent 5 renamed "paused" to "stopped"; older versions ignore it
+		data.Set("paused", "true")
+		data.Set("stopped", "true")
+	}
+	if opts.Sequential {
+		data.Set("sequentialDownload", "true")
+	}
+	if opts.FirstLastPiece {
+		data.Set("firstLastPiecePrio", "true")
 	}
 
 	resp, err := c.client.PostForm(c.baseURL+"/api/v2/torrents/add", data)
@@ -176,17 +222,7 @@ func (c *Client) GetTorrent(hash string) (*models.TorrentStatus, error) {
 	}
 	defer resp.Body.Close()
 
-	var raw []struct {
-		Name     string  `json:"name"`
-		Hash     string  `json:"hash"`
-		State    string  `json:"state"`
-		Progress float64 `json:"progress"`
-		DLSpeed  int64   `json:"dlspeed"`
-		ULSpeed  int64   `json:"upspeed"`
-		Size     int64   `json:"size"`
-		ETA      int     `json:"eta"`
-		Ratio    float64 `json:"ratio"`
-	}
+	var raw []rawTorrent
 
 	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
 		return nil, fmt.Errorf("qbit decode: %w", err)
@@ -196,18 +232,42 @@ func (c *Client) GetTorrent(hash string) (*models.TorrentStatus, error) {
 		return nil, fmt.Errorf("torrent not found: %s", hash)
 	}
 
-	t := raw[0]
-	return &models.TorrentStatus{
-		Name:     t.Name,
-		Hash:     t.Hash,
-		State:    t.State,
-		Progress: t.Progress,
-		DLSpeed:  t.DLSpeed,
-		ULSpeed:  t.ULSpeed,
-		Size:     t.Size,
-		ETA:      t.ETA,
-		Ratio:    t.Ratio,
-	}, nil
+	t := raw[0].toStatus()
+	return &t, nil
+}
+
+// ListFiles returns the files contained in a torrent.
+func (c *Client) ListFiles(hash string) ([]models.TorrentFile, error) {
+	if err := c.ensureLoggedIn(); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{"hash": {hash}}
+	resp, err := c.client.Get(c.baseURL + "/api/v2/torrents/files?" + params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("qbit list files: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("qbit list files failed: %s (status %d)", string(body), resp.StatusCode)
+	}
+
+	var raw []struct {
+		Name     string  `json:"name"`
+		Size     int64   `json:"size"`
+		Progress float64 `json:"progress"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("qbit decode: %w", err)
+	}
+
+	files := make([]models.TorrentFile, len(raw))
+	for i, f := range raw {
+		files[i] = models.TorrentFile{Name: f.Name, Size: f.Size, Progress: f.Progress}
+	}
+	return files, nil
 }
 
 // DeleteTorrent deletes a torrent by hash. If deleteFiles is true, also removes downloaded data.
@@ -235,6 +295,68 @@ func (c *Client) DeleteTorrent(hash string, deleteFiles bool) error {
 	return nil
 }
 
+// RSSRule is an RSS auto-download rule as stored by qBittorrent.
+type RSSRule struct {
+	Enabled          bool     `json:"enabled"`
+	MustContain      string   `json:"mustContain"`
+	MustNotContain   string   `json:"mustNotContain"`
+	UseRegex         bool     `json:"useRegex"`
+	EpisodeFilter    string   `json:"episodeFilter"`
+	SmartFilter      bool     `json:"smartFilter"`
+	AffectedFeeds    []string `json:"affectedFeeds"`
+	AssignedCategory string   `json:"assignedCategory"`
+	SavePath         string   `json:"savePath"`
+	AddPaused        *bool    `json:"addPaused"`
+}
+
+// ListRSSRules returns qBittorrent's RSS auto-download rules, keyed by name.
+func (c *Client) ListRSSRules() (map[string]RSSRule, error) {
+	if err := c.ensureLoggedIn(); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Get(c.baseURL + "/api/v2/rss/rules")
+	if err != nil {
+		return nil, fmt.Errorf("qbit rss rules: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("qbit rss rules failed: %s (status %d)", string(body), resp.StatusCode)
+	}
+
+	var rules map[string]RSSRule
+	if err := json.NewDecoder(resp.Body).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("qbit decode: %w", err)
+	}
+	return rules, nil
+}
+
+// Ping checks that qBittorrent is reachable and accepts our session.
+func (c *Client) Ping() error {
+	if err := c.ensureLoggedIn(); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Get(c.baseURL + "/api/v2/app/version")
+	if err != nil {
+		return fmt.Errorf("qbit ping: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		// Session expired, e.g. qBittorrent restarted; log in again next time
+		c.mu.Lock()
+		c.loggedIn = false
+		c.mu.Unlock()
+	}
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("qbit ping failed (status %d)", resp.StatusCode)
+	}
+	return nil
+}
+
 // IsConfigured returns true if qBittorrent URL is set.
 func (c *Client) IsConfigured() bool {
 	return c.baseURL != ""
@@ -0,0 +1,181 @@
+package qbit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Queue positions for SetQueuePosition.
+const (
+	QueueTop      = "top"
+	QueueBottom   = "bottom"
+	QueueIncrease = "increase"
+	QueueDecrease = "decrease"
+)
+
+// Share limit values with special meaning in SetShareLimits.
+const (
+	ShareLimitGlobal    = -2 // use qBittorrent's global limit
+	ShareLimitUnlimited = -1
+)
+
+// command posts a form to a torrents endpoint. The session is renewed once if
+// it expired.
+func (c *Client) command(endpoint string, data url.Values) error {
+	if err := c.ensureLoggedIn(); err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.client.PostForm(c.baseURL+"/api/v2/torrents/"+endpoint, data)
+		if err != nil {
+			return fmt.Errorf("qbit %s: %w", endpoint, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusForbidden && attempt == 0:
+			c.loggedIn = false
+			if err := c.Login(); err != nil {
+				return err
+			}
+			continue
+		case resp.StatusCode != 200:
+			return &statusError{endpoint: endpoint, body: strings.TrimSpace(string(body)), code: resp.StatusCode}
+		}
+		return nil
+	}
+}
+
+// statusError is a command qBittorrent answered with a non-200 status.
+type statusError struct {
+	endpoint, body string
+	code           int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("qbit %s failed: %s (status %d)", e.endpoint, e.body, e.code)
+}
+
+// endpointMissing reports whether a command failed because this qBittorrent
+// version doesn't have the endpoint, so a renamed one can be tried.
+func endpointMissing(err error) bool {
+	var se *statusError
+	return errors.As(err, &se) && se.code == http.StatusNotFound
+}
+
+// hashList joins hashes the way the Web API expects them.
+func hashList(hashes []string) url.Values {
+	return url.Values{"hashes": {strings.Join(hashes, "|")}}
+}
+
+// Pause stops torrents. qBittorrent 5 renamed pause to stop; both are tried.
+func (c *Client) Pause(hashes []string) error {
+	err := c.command("stop", hashList(hashes))
+	if endpointMissing(err) {
+		err = c.command("pause", hashList(hashes))
+	}
+	return err
+}
+
+// Resume starts paused torrents.
+func (c *Client) Resume(hashes []string) error {
+	err := c.command("start", hashList(hashes))
+	if endpointMissing(err) {
+		err = c.command("resume", hashList(hashes))
+	}
+	return err
+}
+
+// Recheck forces a hash check of torrents' data.
+func (c *Client) Recheck(hashes []string) error {
+	return c.command("recheck", hashList(hashes))
+}
+
+// Reannounce asks torrents' trackers for peers now.
+func (c *Client) Reannounce(hashes []string) error {
+	return c.command("reannounce", hashList(hashes))
+}
+
+// SetCategory moves torrents to a category; "" removes their category.
+func (c *Client) SetCategory(hashes []string, category string) error {
+	data := hashList(hashes)
+	data.Set("category", category)
+	return c.command("setCategory", data)
+}
+
+// AddTags adds tags to torrents, creating them as needed.
+func (c *Client) AddTags(hashes, tags []string) error {
+	data := hashList(hashes)
+	data.Set("tags", strings.Join(tags, ","))
+	return c.command("addTags", data)
+}
+
+// RemoveTags removes tags from torrents.
+func (c *Client) RemoveTags(hashes, tags []string) error {
+	data := hashList(hashes)
+	data.Set("tags", strings.Join(tags, ","))
+	return c.command("removeTags", data)
+}
+
+// SetDownloadLimit caps torrents' download speed in bytes per second; 0 removes the cap.
+func (c *Client) SetDownloadLimit(hashes []string, limit int64) error {
+	data := hashList(hashes)
+	data.Set("limit", strconv.FormatInt(limit, 10))
+	return c.command("setDownloadLimit", data)
+}
+
+// SetUploadLimit caps torrents' upload speed in bytes per second; 0 removes the cap.
+func (c *Client) SetUploadLimit(hashes []string, limit int64) error {
+	data := hashList(hashes)
+	data.Set("limit", strconv.FormatInt(limit, 10))
+	return c.command("setUploadLimit", data)
+}
+
+// SetShareLimits sets when torrents stop seeding: a ratio, minutes seeded, and
+// minutes seeded without activity. Each may be ShareLimitGlobal or ShareLimitUnlimited.
+func (c *Client) SetShareLimits(hashes []string, ratio float64, seedingMinutes, inactiveMinutes int) error {
+	data := hashList(hashes)
+	data.Set("ratioLimit", strconv.FormatFloat(ratio, 'f', -1, 64))
+	data.Set("seedingTimeLimit", strconv.Itoa(seedingMinutes))
+	data.Set("inactiveSeedingTimeLimit", strconv.Itoa(inactiveMinutes))
+	return c.command("setShareLimits", data)
+}
+
+// Rename changes a torrent's display name. Files on disk keep their names.
+func (c *Client) Rename(hash, name string) error {
+	return c.command("rename", url.Values{"hash": {hash}, "name": {name}})
+}
+
+// SetLocation moves torrents' data to a new save path.
+func (c *Client) SetLocation(hashes []string, location string) error {
+	data := hashList(hashes)
+	data.Set("location", location)
+	return c.command("setLocation", data)
+}
+
+// SetQueuePosition moves torrents in the download queue. Queueing must be
+// enabled in qBittorrent.
+func (c *Client) SetQueuePosition(hashes []string, position string) error {
+	endpoints := map[string]string{
+		QueueTop:      "topPrio",
+		QueueBottom:   "bottomPrio",
+		QueueIncrease: "increasePrio",
+		QueueDecrease: "decreasePrio",
+	}
+	endpoint, ok := endpoints[position]
+	if !ok {
+		return fmt.Errorf("unknown queue position %q", position)
+	}
+	err := c.command(endpoint, hashList(hashes))
+	if err != nil && strings.Contains(err.Error(), "status 409") {
+		return fmt.Errorf("torrent queueing is disabled in qBittorrent")
+	}
+	return err
+}
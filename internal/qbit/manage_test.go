@@ -0,0 +1,72 @@
+package qbit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeQbit serves login and records torrent commands. Endpoints in missing
+// answer 404, like qBittorrent versions that predate or dropped them.
+func fakeQbit(t *testing.T, missing ...string) (*Client, *[]string) {
+	t.Helper()
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/auth/login" {
+			w.Write([]byte("Ok."))
+			return
+		}
+		endpoint := strings.TrimPrefix(r.URL.Path, "/api/v2/torrents/")
+		for _, m := range missing {
+			if endpoint == m {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		r.ParseForm()
+		calls = append(calls, endpoint+" "+r.PostForm.Encode())
+	}))
+	t.Cleanup(srv.Close)
+	return New(srv.URL, "admin", "secret"), &calls
+}
+
+func TestPauseFallsBackToLegacyEndpoint(t *testing.T) {
+	c, calls := fakeQbit(t, "stop")
+	if err := c.Pause([]string{"aa", "bb"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "pause hashes=aa%7Cbb"
+	if len(*calls) != 1 || (*calls)[0] != want {
+		t.Errorf("calls = %v, want [%s]", *calls, want)
+	}
+}
+
+func TestSetShareLimits(t *testing.T) {
+	c, calls := fakeQbit(t)
+	if err := c.SetShareLimits([]string{"aa"}, 1.5, ShareLimitUnlimited, ShareLimitGlobal); err != nil {
+		t.Fatal(err)
+	}
+	want := "setShareLimits hashes=aa&inactiveSeedingTimeLimit=-2&ratioLimit=1.5&seedingTimeLimit=-1"
+	if len(*calls) != 1 || (*calls)[0] != want {
+		t.Errorf("calls = %v, want [%s]", *calls, want)
+	}
+}
+
+func TestSetQueuePositionRejectsUnknown(t *testing.T) {
+	c, calls := fakeQbit(t)
+	if err := c.SetQueuePosition([]string{"aa"}, "sideways"); err == nil {
+		t.Error("expected error for unknown position")
+	}
+	if len(*calls) != 0 {
+		t.Errorf("unexpected calls %v", *calls)
+	}
+}
+
+func TestCommandReportsMissingEndpoint(t *testing.T) {
+	c, _ := fakeQbit(t, "recheck")
+	err := c.Recheck([]string{"aa"})
+	if err == nil || !strings.Contains(err.Error(), "qbit recheck failed") || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("err = %v, want a recheck status 404 error", err)
+	}
+}
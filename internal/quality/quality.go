@@ -0,0 +1,87 @@
+package quality
+
+import (
+	"fmt"
+	"strings"
+
+	"link-anime/internal/models"
+	"link-anime/internal/parser"
+)
+
+// Score weights: any resolution step outweighs any source step, which in
+// turn outweighs typical group and codec preferences.
+const (
+	resolutionWeight = 100
+	sourceWeight     = 10
+)
+
+// defaultResolutions ranks resolutions when a profile lists none.
+var defaultResolutions = []string{"2160p", "1080p", "720p", "576p", "480p"}
+
+// Score rates a release against a profile; higher is better. Resolutions and
+// sources earn more the earlier they appear in the profile's lists, and
+// matching groups and codecs add their configured scores.
+func Score(p *models.QualityProfile, rel parser.Release) int {
+	resolutions := p.Resolutions
+	if len(resolutions) == 0 {
+		resolutions = defaultResolutions
+	}
+
+	score := rankScore(resolutions, rel.Resolution) * resolutionWeight
+	score += rankScore(p.Sources, rel.Source) * sourceWeight
+
+	for _, g := range p.Groups {
+		if rel.Group != "" && strings.EqualFold(g.Value, rel.Group) {
+			score += g.Score
+		}
+	}
+	for _, c := range p.Codecs {
+		if rel.Codec != "" && strings.EqualFold(c.Value, rel.Codec) {
+			score += c.Score
+		}
+	}
+
+	return score
+}
+
+// rankScore returns len(list) for the first entry down to 1 for the last,
+// and 0 when value is not listed.
+func rankScore(list []string, value string) int {
+	if value == "" {
+		return 0
+	}
+	for i, v := range list {
+		if strings.EqualFold(v, value) {
+			return len(list) - i
+		}
+	}
+	return 0
+}
+
+// Validate checks a profile before it is saved.
+func Validate(p *models.QualityProfile) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if p.DelayMinutes < 0 {
+		return fmt.Errorf("delayMinutes must not be negative")
+	}
+	if p.DelayMinutes > 24*60 {
+		return fmt.Errorf("delayMinutes must be at most one day")
+	}
+
+	// Store empty lists rather than null
+	if p.Resolutions == nil {
+		p.Resolutions = []string{}
+	}
+	if p.Sources == nil {
+		p.Sources = []string{}
+	}
+	if p.Groups == nil {
+		p.Groups = []models.ScoreEntry{}
+	}
+	if p.Codecs == nil {
+		p.Codecs = []models.ScoreEntry{}
+	}
+	return nil
+}
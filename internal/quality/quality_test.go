@@ -0,0 +1,34 @@
+package quality
+
+import (
+	"testing"
+
+	"link-anime/internal/models"
+	"link-anime/internal/parser"
+)
+
+func TestScore(t *testing.T) {
+	profile := &models.QualityProfile{
+		Resolutions: []string{"1080p", "720p"},
+		Sources:     []string{"BD", "WEB"},
+		Groups:      []models.ScoreEntry{{Value: "SubsPlease", Score: 5}, {Value: "BadGroup", Score: -50}},
+		Codecs:      []models.ScoreEntry{{Value: "HEVC", Score: 3}},
+	}
+
+	tests := []struct {
+		title string
+		score int
+	}{
+		{"[SubsPlease] Frieren - 05 (1080p) [WEB]", 200 + 10 + 5},
+		{"[SubsPlease] Frieren - 05 (720p) [WEB]", 100 + 10 + 5},
+		{"[Other] Frieren - 05 [1080p BD HEVC]", 200 + 20 + 3},
+		{"[BadGroup] Frieren - 05 (1080p)", 200 - 50},
+		{"[Other] Frieren - 05 (480p)", 0},
+	}
+
+	for _, tt := range tests {
+		if got := Score(profile, parser.ParseRelease(tt.title)); got != tt.score {
+			t.Errorf("Score(%q) = %d, want %d", tt.title, got, tt.score)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package quality
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"link-anime/internal/database"
+	"link-anime/internal/models"
+)
+
+const profileColumns = `id, name, resolutions, sources, groups, codecs, min_score, delay_minutes, created_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanProfile(row rowScanner) (models.QualityProfile, error) {
+	var p models.QualityProfile
+	var resolutions, sources, groups, codecs string
+	err := row.Scan(&p.ID, &p.Name, &resolutions, &sources, &groups, &codecs,
+		&p.MinScore, &p.DelayMinutes, &p.CreatedAt)
+	if err != nil {
+		return p, err
+	}
+	json.Unmarshal([]byte(resolutions), &p.Resolutions)
+	json.Unmarshal([]byte(sources), &p.Sources)
+	json.Unmarshal([]byte(groups), &p.Groups)
+	json.Unmarshal([]byte(codecs), &p.Codecs)
+	return p, nil
+}
+
+// List returns all quality profiles.
+func List() ([]models.QualityProfile, error) {
+	rows, err := database.DB.Query(`SELECT ` + profileColumns + ` FROM quality_profiles ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list profiles: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []models.QualityProfile
+	for rows.Next() {
+		p, err := scanProfile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan profile: %w", err)
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+// Get returns a profile by ID, or nil if it does not exist.
+func Get(id int64) (*models.QualityProfile, error) {
+	p, err := scanProfile(database.DB.QueryRow(`SELECT `+profileColumns+` FROM quality_profiles WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get profile: %w", err)
+	}
+	return &p, nil
+}
+
+// Create inserts a new profile.
+func Create(p *models.QualityProfile) error {
+	result, err := database.DB.Exec(`
+		INSERT INTO quality_profiles (name, resolutions, sources, groups, codecs, min_score, delay_minutes)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, p.Name, encode(p.Resolutions), encode(p.Sources), encode(p.Groups), encode(p.Codecs),
+		p.MinScore, p.DelayMinutes)
+	if err != nil {
+		return fmt.Errorf("create profile: %w", err)
+	}
+	p.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// Update saves changes to an existing profile.
+func Update(p *models.QualityProfile) error {
+	_, err := database.DB.Exec(`
+		UPDATE quality_profiles SET name = ?, resolutions = ?, sources = ?, groups = ?, codecs = ?,
+		       min_score = ?, delay_minutes = ?
+		WHERE id = ?
+	`, p.Name, encode(p.Resolutions), encode(p.Sources), encode(p.Groups), encode(p.Codecs),
+		p.MinScore, p.DelayMinutes, p.ID)
+	if err != nil {
+		return fmt.Errorf("update profile: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a profile and detaches it from any rules using it.
+func Delete(id int64) error {
+	if _, err := database.DB.Exec("UPDATE rss_rules SET profile_id = 0 WHERE profile_id = ?", id); err != nil {
+		return fmt.Errorf("detach profile: %w", err)
+	}
+	if _, err := database.DB.Exec("DELETE FROM quality_profiles WHERE id = ?", id); err != nil {
+		return fmt.Errorf("delete profile: %w", err)
+	}
+	return nil
+}
+
+func encode(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
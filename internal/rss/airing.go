@@ -0,0 +1,239 @@
+package rss
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"link-anime/internal/models"
+	"link-anime/internal/notify"
+)
+
+const (
+	airSamples     = 8              // recent episodes a learned schedule is based on
+	minAirSamples  = 3              // episodes needed before a schedule is learned
+	airBurstWindow = time.Hour      // matches this close together are one burst, e.g. a backfill
+	missedGrace    = 12 * time.Hour // how long after the expected air time an episode may take
+)
+
+// Air schedule sources.
+const (
+	AirManual  = "manual"
+	AirLearned = "learned"
+)
+
+type episodeTime struct {
+	season, episode int
+	matched         time.Time
+}
+
+// airPattern is a weekly release slot in UTC.
+type airPattern struct {
+	day     time.Weekday
+	minute  int // minutes after midnight
+	source  string
+	samples int
+}
+
+func validateAirSchedule(rule *models.RSSRule) error {
+	if rule.AirDay == nil && rule.AirTime == "" {
+		return nil
+	}
+	if rule.AirDay == nil || rule.AirTime == "" {
+		return fmt.Errorf("airDay and airTime must be set together")
+	}
+	if *rule.AirDay < 0 || *rule.AirDay > 6 {
+		return fmt.Errorf("airDay must be 0 (Sunday) to 6 (Saturday)")
+	}
+	if _, err := time.Parse("15:04", rule.AirTime); err != nil {
+		return fmt.Errorf("airTime must be HH:MM (UTC)")
+	}
+	return nil
+}
+
+// learnPattern finds the weekday most recent episodes were first matched on,
+// and the median time of day on it. Bursts, such as a backfill grabbing
+// several episodes at once, count once. ok is false without a clear pattern.
+func learnPattern(times []time.Time) (airPattern, bool) {
+	sorted := append([]time.Time(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	var samples []time.Time
+	for _, t := range sorted {
+		if n := len(samples); n > 0 && t.Sub(samples[n-1]) < airBurstWindow {
+			continue
+		}
+		samples = append(samples, t.UTC())
+	}
+	if len(samples) > airSamples {
+		samples = samples[len(samples)-airSamples:]
+	}
+	if len(samples) < minAirSamples {
+		return airPattern{}, false
+	}
+
+	byDay := map[time.Weekday][]int{}
+	best := time.Sunday
+	for _, t := range samples {
+		byDay[t.Weekday()] = append(byDay[t.Weekday()], t.Hour()*60+t.Minute())
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if len(byDay[day]) > len(byDay[best]) {
+			best = day
+		}
+	}
+
+	// Most episodes must share the day for it to be a weekly show
+	minutes := byDay[best]
+	if len(minutes) < 2 || float64(len(minutes)) < 0.6*float64(len(samples)) {
+		return airPattern{}, false
+	}
+	sort.Ints(minutes)
+	return airPattern{day: best, minute: minutes[len(minutes)/2], source: AirLearned, samples: len(samples)}, true
+}
+
+// nextAir returns the first slot of the pattern strictly after t.
+func nextAir(p airPattern, t time.Time) time.Time {
+	t = t.UTC()
+	slot := time.Date(t.Year(), t.Month(), t.Day(), p.minute/60, p.minute%60, 0, 0, time.UTC)
+	slot = slot.AddDate(0, 0, (int(p.day)-int(t.Weekday())+7)%7)
+	if !slot.After(t) {
+		slot = slot.AddDate(0, 0, 7)
+	}
+	return slot
+}
+
+// rulePattern returns the rule's manual schedule, or one learned from its
+// episode history.
+func rulePattern(rule models.RSSRule, history []episodeTime) (airPattern, bool) {
+	if rule.AirDay != nil && rule.AirTime != "" {
+		t, err := time.Parse("15:04", rule.AirTime)
+		if err == nil {
+			return airPattern{day: time.Weekday(*rule.AirDay), minute: t.Hour()*60 + t.Minute(), source: AirManual}, true
+		}
+	}
+	times := make([]time.Time, len(history))
+	for i, et := range history {
+		times[i] = et.matched
+	}
+	return learnPattern(times)
+}
+
+// scheduleEntry works out when a rule expects its next episode. ok is false
+// for movies, finished seasons and rules without a schedule.
+func scheduleEntry(rule models.RSSRule, now time.Time) (models.ScheduleEntry, bool, error) {
+	if rule.MediaType == "movie" {
+		return models.ScheduleEntry{}, false, nil
+	}
+	history, err := episodeTimes(rule.ID)
+	if err != nil {
+		return models.ScheduleEntry{}, false, err
+	}
+	pattern, ok := rulePattern(rule, history)
+	if !ok {
+		return models.ScheduleEntry{}, false, nil
+	}
+
+	entry := models.ScheduleEntry{
+		RuleID:   rule.ID,
+		RuleName: rule.Name,
+		ShowName: rule.ShowName,
+		Season:   rule.Season,
+		AirDay:   int(pattern.day),
+		AirTime:  fmt.Sprintf("%02d:%02d", pattern.minute/60, pattern.minute%60),
+		Source:   pattern.source,
+		Samples:  pattern.samples,
+	}
+
+	// Next episode follows the latest one of the rule's season
+	after := rule.CreatedAt
+	last := 0
+	for _, et := range history {
+		if et.season == rule.Season && et.episode > last {
+			last = et.episode
+		}
+		if entry.LastGrab == nil || et.matched.After(*entry.LastGrab) {
+			matched := et.matched
+			entry.LastGrab = &matched
+		}
+	}
+	if rule.ExpectedEpisodes > 0 && last >= rule.ExpectedEpisodes {
+		return models.ScheduleEntry{}, false, nil
+	}
+	if last > 0 {
+		next := last + 1
+		entry.Episode = &next
+	}
+	// A release can beat its usual slot by a little; skip to the following week
+	if entry.LastGrab != nil && entry.LastGrab.Add(24*time.Hour).After(after) {
+		after = entry.LastGrab.Add(24 * time.Hour)
+	}
+
+	entry.NextAir = nextAir(pattern, after)
+	entry.Missed = now.After(entry.NextAir.Add(missedGrace))
+	return entry, true, nil
+}
+
+// Schedule returns the expected next episode of every enabled rule with a
+// known schedule, soonest first.
+func Schedule() ([]models.ScheduleEntry, error) {
+	rules, err := ListRules()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entries := []models.ScheduleEntry{}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		entry, ok, err := scheduleEntry(rule, now)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].NextAir.Before(entries[j].NextAir) })
+	return entries, nil
+}
+
+// checkMissed reports an episode that hasn't matched within missedGrace of
+// its expected air time, once per expected episode.
+func (p *Poller) checkMissed(rule models.RSSRule) {
+	entry, ok, err := scheduleEntry(rule, time.Now())
+	if err != nil {
+		log.Printf("RSS poll [%s]: %v", rule.Name, err)
+		return
+	}
+	if !ok || !entry.Missed {
+		return
+	}
+	if rule.MissedAlertAt != nil && rule.MissedAlertAt.After(entry.NextAir) {
+		return // already reported
+	}
+	setMissedAlert(rule.ID)
+
+	episode := "next episode"
+	if entry.Episode != nil {
+		episode = fmt.Sprintf("episode %d", *entry.Episode)
+	}
+	log.Printf("RSS missed [%s]: %s expected %s", rule.Name, episode, entry.NextAir.Format(time.RFC1123))
+
+	p.hub.Broadcast(models.WSMessage{
+		Type: "rss_episode_missed",
+		Data: entry,
+	})
+
+	if n := p.notifier(); n != nil {
+		n.Send("Episode Missing", fmt.Sprintf("%s %s hasn't shown up", rule.ShowName, episode), []notify.Field{
+			{Name: "Rule", Value: rule.Name},
+			{Name: "Season", Value: strconv.Itoa(rule.Season)},
+			{Name: "Expected", Value: entry.NextAir.Format("Mon 2 Jan 15:04 MST")},
+		}, "red")
+	}
+}
@@ -0,0 +1,53 @@
+package rss
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLearnPattern(t *testing.T) {
+	// Saturdays around 16:00 UTC, one late release, and a backfill burst
+	base := time.Date(2026, 1, 3, 16, 0, 0, 0, time.UTC) // a Saturday
+	times := []time.Time{
+		base.Add(-2 * time.Minute),
+		base.Add(-1 * time.Minute), // same burst
+		base.AddDate(0, 0, 7).Add(5 * time.Minute),
+		base.AddDate(0, 0, 14).Add(2 * time.Minute),
+		base.AddDate(0, 0, 22), // a day late
+		base.AddDate(0, 0, 28).Add(1 * time.Minute),
+	}
+
+	p, ok := learnPattern(times)
+	if !ok {
+		t.Fatal("no pattern learned")
+	}
+	if p.day != time.Saturday || p.minute != 16*60+2 || p.samples != 5 {
+		t.Errorf("got day %s minute %d samples %d, want Saturday 962 5", p.day, p.minute, p.samples)
+	}
+
+	if _, ok := learnPattern(times[:3]); ok {
+		t.Error("learned a pattern from two samples")
+	}
+
+	scattered := []time.Time{base, base.AddDate(0, 0, 8), base.AddDate(0, 0, 16), base.AddDate(0, 0, 24)}
+	if _, ok := learnPattern(scattered); ok {
+		t.Error("learned a pattern from releases on different days")
+	}
+}
+
+func TestNextAir(t *testing.T) {
+	p := airPattern{day: time.Saturday, minute: 16 * 60}
+	tests := []struct {
+		after time.Time
+		want  time.Time
+	}{
+		{time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), time.Date(2026, 1, 3, 16, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 1, 3, 15, 0, 0, 0, time.UTC), time.Date(2026, 1, 3, 16, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 1, 3, 16, 0, 0, 0, time.UTC), time.Date(2026, 1, 10, 16, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := nextAir(p, tt.after); !got.Equal(tt.want) {
+			t.Errorf("nextAir(%s) = %s, want %s", tt.after, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package rss
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"link-anime/internal/linker"
+	"link-anime/internal/models"
+	"link-anime/internal/ws"
+)
+
+// DirsGetter returns the current download, media and movies directories.
+type DirsGetter func() (download, media, movies string)
+
+// AutoLinker links completed RSS downloads into the library for rules with
+// AutoLink set. When the download was an upgrade, the library files the
+// superseded release linked are removed.
+type AutoLinker struct {
+	hub  *ws.Hub
+	dirs DirsGetter
+}
+
+// NewAutoLinker creates an auto-linker. dirs is a function so it picks up settings changes.
+func NewAutoLinker(hub *ws.Hub, dirs DirsGetter) *AutoLinker {
+	return &AutoLinker{hub: hub, dirs: dirs}
+}
+
+// HandleComplete is called by the download monitor when a torrent finishes.
+// Only matches with a known info hash can be tied back to their torrent.
+func (a *AutoLinker) HandleComplete(t models.TorrentStatus) {
+	matches, err := matchesByInfoHash(strings.ToLower(t.Hash))
+	if err != nil {
+		log.Printf("RSS autolink: %v", err)
+		return
+	}
+
+	for _, m := range matches {
+		if m.Status != "downloaded" {
+			continue
+		}
+		rule, err := GetRule(m.RuleID)
+		if err != nil || rule == nil || !rule.AutoLink {
+			continue
+		}
+		a.link(*rule, m, t)
+	}
+}
+
+func (a *AutoLinker) link(rule models.RSSRule, m models.RSSMatch, t models.TorrentStatus) {
+	download, media, movies := a.dirs()
+
+	season := rule.Season
+	if m.Season != nil {
+		season = *m.Season
+	}
+	dir, source := torrentSource(t, download)
+	req := models.LinkRequest{
+		Source: source,
+		Type:   rule.MediaType,
+		Name:   rule.ShowName,
+		Season: season,
+	}
+
+	result, err := linker.Link(req, dir, media, movies, a.hub)
+	if err != nil {
+		log.Printf("RSS autolink [%s]: %s: %v", rule.Name, t.Name, err)
+		return
+	}
+	if result.Linked == 0 {
+		log.Printf("RSS autolink [%s]: %s: nothing linked (%d skipped, %d failed)",
+			rule.Name, t.Name, result.Skipped, result.Failed)
+		return
+	}
+
+	log.Printf("RSS autolink [%s]: linked %d file(s) from %s", rule.Name, result.Linked, t.Name)
+	if err := SetMatchStatus(m.ID, "linked"); err != nil {
+		log.Printf("RSS autolink [%s]: %v", rule.Name, err)
+	}
+	if err := recordLinkedFiles(m.ID, result.Files); err != nil {
+		log.Printf("RSS autolink [%s]: %v", rule.Name, err)
+	}
+
+	if m.UpgradeOf == nil || rule.MediaType == "movie" {
+		return
+	}
+
+	prev, err := matchLinkedFiles(*m.UpgradeOf)
+	if err != nil {
+		log.Printf("RSS autolink [%s]: %v", rule.Name, err)
+		return
+	}
+	keep := append(append([]string{}, result.Files...), result.SkippedFiles...)
+	remove, kept := supersededFiles(prev, keep)
+	for _, reason := range kept {
+		log.Printf("RSS autolink [%s]: keeping %s", rule.Name, reason)
+	}
+	for _, path := range remove {
+		if err := os.Remove(path); err != nil {
+			log.Printf("RSS autolink [%s]: remove %s: %v", rule.Name, path, err)
+			continue
+		}
+		log.Printf("RSS autolink [%s]: replaced %s", rule.Name, filepath.Base(path))
+	}
+	if err := SetMatchStatus(*m.UpgradeOf, "replaced"); err != nil {
+		log.Printf("RSS autolink [%s]: %v", rule.Name, err)
+	}
+}
+
+// torrentSource returns the directory holding a torrent's content and its
+// name there. Rules and categories can save outside the download dir, so
+// qBittorrent's content path and save path are tried first; qBittorrent may
+// run in another container, so they're only used if they exist here.
+func torrentSource(t models.TorrentStatus, downloadDir string) (dir, name string) {
+	if t.ContentPath != "" {
+		if _, err := os.Stat(t.ContentPath); err == nil {
+			return filepath.Dir(t.ContentPath), filepath.Base(t.ContentPath)
+		}
+	}
+	if t.SavePath != "" {
+		if _, err := os.Stat(filepath.Join(t.SavePath, t.Name)); err == nil {
+			return t.SavePath, t.Name
+		}
+	}
+	return downloadDir, t.Name
+}
+
+// fileID identifies a file's data independently of its path.
+type fileID struct {
+	Dev, Ino uint64
+}
+
+// linkedFile is a library file an auto-linked match created.
+type linkedFile struct {
+	Path string
+	ID   fileID
+}
+
+func statFileID(path string) (fileID, bool) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return fileID{}, false
+	}
+	return fileID{Dev: uint64(st.Dev), Ino: uint64(st.Ino)}, true
+}
+
+// supersededFiles picks which of the files a replaced match linked can be
+// removed: those the upgrade didn't link or skip, that are still the file that
+// was linked, and that aren't the last copy of their data. kept explains the rest.
+func supersededFiles(prev []linkedFile, keep []string) (remove, kept []string) {
+	inUse := make(map[string]bool, len(keep))
+	for _, f := range keep {
+		inUse[f] = true
+	}
+
+	for _, f := range prev {
+		name := filepath.Base(f.Path)
+		if inUse[f.Path] {
+			kept = append(kept, name+": used by the upgrade")
+			continue
+		}
+		id, ok := statFileID(f.Path)
+		if !ok {
+			continue // already gone
+		}
+		if id != f.ID {
+			kept = append(kept, name+": replaced since it was linked")
+			continue
+		}
+		if !linker.FileSafety(f.Path).Safe {
+			kept = append(kept, name+": last copy of the data")
+			continue
+		}
+		remove = append(remove, f.Path)
+	}
+	return remove, kept
+}
@@ -0,0 +1,129 @@
+package rss
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"link-anime/internal/models"
+)
+
+func TestSupersededFiles(t *testing.T) {
+	root := t.TempDir()
+	downloads := filepath.Join(root, "downloads")
+	season := filepath.Join(root, "media", "Show", "Season 1")
+	for _, d := range []string{downloads, season} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(path string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hardlink := func(name string) string {
+		t.Helper()
+		src, dst := filepath.Join(downloads, name), filepath.Join(season, name)
+		write(src)
+		if err := os.Link(src, dst); err != nil {
+			t.Fatal(err)
+		}
+		return dst
+	}
+	record := func(path string) linkedFile {
+		t.Helper()
+		id, ok := statFileID(path)
+		if !ok {
+			t.Fatalf("stat %s", path)
+		}
+		return linkedFile{Path: path, ID: id}
+	}
+
+	old := hardlink("[Old] Show - 05 [720p].mkv")       // linked by the replaced match
+	skipped := hardlink("[Same] Show - 05 [1080p].mkv") // replaced match's file, reused by the upgrade
+	lastCopy := hardlink("[Old] Show - 05v2 [720p].mkv")
+	os.Remove(filepath.Join(downloads, filepath.Base(lastCopy)))
+	swapped := hardlink("[Old] Show - 05 [480p].mkv")
+	prev := []linkedFile{record(old), record(skipped), record(lastCopy), record(swapped)}
+	os.Remove(swapped)
+	write(swapped) // user put a different file at the same path
+
+	hand := filepath.Join(season, "Show - 05 (hand linked).mkv")
+	write(hand)
+
+	remove, kept := supersededFiles(prev, []string{skipped})
+	if want := []string{old}; !reflect.DeepEqual(remove, want) {
+		t.Errorf("remove = %v, want %v", remove, want)
+	}
+	if len(kept) != 3 {
+		t.Errorf("kept = %v, want 3 entries", kept)
+	}
+	for _, p := range remove {
+		if p == hand {
+			t.Error("hand-linked file selected for removal")
+		}
+	}
+}
+
+func TestAutoLinkCustomSavePath(t *testing.T) {
+	_, rule := retryFixture(t)
+	root := t.TempDir()
+	download := filepath.Join(root, "downloads")
+	saveTo := filepath.Join(root, "anime-seasonal") // the rule's own save path
+	media := filepath.Join(root, "media")
+	torrentDir := filepath.Join(saveTo, "[Group] Show - 03 [1080p]")
+	for _, d := range []string{download, torrentDir, media} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	video := filepath.Join(torrentDir, "[Group] Show - 03 [1080p].mkv")
+	if err := os.WriteFile(video, []byte("video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rule.SavePath = saveTo
+	rule.AutoLink = true
+	id := insertTestMatch(t, rule.ID, "[Group] Show - 03 [1080p]", "downloaded", "")
+	m, err := GetMatch(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAutoLinker(nil, func() (string, string, string) { return download, media, "" })
+	a.link(rule, *m, models.TorrentStatus{Name: filepath.Base(torrentDir), SavePath: saveTo})
+
+	linked := filepath.Join(media, "Show", "Season 1", filepath.Base(video))
+	if _, err := os.Stat(linked); err != nil {
+		t.Fatalf("expected %s to be linked: %v", linked, err)
+	}
+	if m, _ = GetMatch(id); m.Status != "linked" {
+		t.Errorf("match status = %q, want linked", m.Status)
+	}
+}
+
+func TestTorrentSource(t *testing.T) {
+	root := t.TempDir()
+	single := filepath.Join(root, "custom", "Show - 01.mkv")
+	os.MkdirAll(filepath.Dir(single), 0755)
+	os.WriteFile(single, nil, 0644)
+
+	tests := []struct {
+		name     string
+		torrent  models.TorrentStatus
+		dir, src string
+	}{
+		{"content path", models.TorrentStatus{Name: "Show - 01.mkv", ContentPath: single}, filepath.Dir(single), "Show - 01.mkv"},
+		{"save path", models.TorrentStatus{Name: "Show - 01.mkv", SavePath: filepath.Dir(single)}, filepath.Dir(single), "Show - 01.mkv"},
+		{"paths from another container", models.TorrentStatus{Name: "Show - 01.mkv", SavePath: "/downloads/custom", ContentPath: "/downloads/custom/Show - 01.mkv"}, "/dl", "Show - 01.mkv"},
+	}
+	for _, tt := range tests {
+		dir, src := torrentSource(tt.torrent, "/dl")
+		if dir != tt.dir || src != tt.src {
+			t.Errorf("%s: got (%s, %s), want (%s, %s)", tt.name, dir, src, tt.dir, tt.src)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package rss
+
+import (
+	"fmt"
+	"log"
+
+	"link-anime/internal/models"
+	"link-anime/internal/parser"
+	"link-anime/internal/scanner"
+)
+
+// Backfill modes for items already in a new rule's feed.
+const (
+	BackfillMissing = "missing" // grab episodes the library doesn't have
+	BackfillBatch   = "batch"   // grab the best batch covering missing episodes, if any
+	BackfillNone    = "none"    // only watch for new items
+)
+
+// ValidBackfill reports whether mode is a known backfill mode.
+func ValidBackfill(mode string) bool {
+	switch mode {
+	case BackfillMissing, BackfillBatch, BackfillNone:
+		return true
+	}
+	return false
+}
+
+// Backfill handles the items currently in a new rule's feed according to
+// mode. Everything not grabbed is recorded so later polls leave it alone;
+// episodes already in the library count as taken for upgrade decisions.
+func (p *Poller) Backfill(rule models.RSSRule, mode string) (*models.BackfillResult, error) {
+	p.cycleMu.Lock()
+	defer p.cycleMu.Unlock()
+
+	filter, err := newRuleFilter(rule)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := ruleProfile(rule)
+	if err != nil {
+		return nil, err
+	}
+	results, err := fetchSource(rule)
+	if err != nil {
+		return nil, fmt.Errorf("fetch feed: %w", err)
+	}
+
+	res := &models.BackfillResult{Mode: mode, Grabbed: []string{}}
+	matches := candidates(rule, profile, filter, results)
+
+	// Episodes in the library, per season
+	_, mediaDir, _ := p.dirs()
+	library := make(map[int]map[int]bool)
+	have := func(season int) map[int]bool {
+		if _, ok := library[season]; !ok {
+			library[season] = scanner.LibraryEpisodes(mediaDir, rule.ShowName, season)
+		}
+		return library[season]
+	}
+
+	skip := func(m *models.RSSMatch, status, reason string) {
+		if isAlreadyMatched(rule.ID, m.Hash, hashTitle(m.Title)) {
+			return
+		}
+		m.Status, m.Reason = status, reason
+		if err := InsertMatch(m); err != nil {
+			log.Printf("RSS backfill [%s]: failed to record match: %v", rule.Name, err)
+		}
+		if status == "library" {
+			res.InLibrary++
+		} else {
+			res.Skipped++
+		}
+	}
+
+	var batch *models.RSSMatch
+	if mode == BackfillBatch && rule.MediaType != "movie" {
+		batch = pickBatch(matches, have(rule.Season))
+	}
+
+	for i := range matches {
+		m := &matches[i]
+		switch {
+		case mode == BackfillNone:
+			skip(m, decisionSkip, "in feed before the rule was created")
+		case mode == BackfillBatch && rule.MediaType != "movie":
+			if batch == nil || m.Hash != batch.Hash {
+				skip(m, decisionSkip, "backfill takes a single batch")
+				continue
+			}
+			if p.process(rule, profile, m) {
+				res.Grabbed = append(res.Grabbed, m.Title)
+			}
+		default:
+			if m.Episode != nil && have(*m.Season)[*m.Episode] {
+				skip(m, "library", "already in library")
+				continue
+			}
+			if m.Episode == nil && rule.MediaType != "movie" {
+				skip(m, decisionSkip, "backfill takes single episodes")
+				continue
+			}
+			if p.process(rule, profile, m) {
+				res.Grabbed = append(res.Grabbed, m.Title)
+			}
+		}
+	}
+
+	log.Printf("RSS backfill [%s]: mode %s, %d grabbed, %d skipped, %d in library",
+		rule.Name, mode, len(res.Grabbed), res.Skipped, res.InLibrary)
+	return res, nil
+}
+
+// pickBatch returns the batch covering the most missing episodes, preferring
+// the higher score on ties, or nil if no batch covers any. Batches with no
+// episode range are assumed to cover the whole season.
+func pickBatch(matches []models.RSSMatch, have map[int]bool) *models.RSSMatch {
+	type batchRange struct{ first, last int }
+
+	upTo := 0
+	ranges := make(map[int]batchRange)
+	for i, m := range matches {
+		if m.Episode != nil {
+			upTo = max(upTo, *m.Episode)
+			continue
+		}
+		rel := parser.ParseRelease(m.Title)
+		if !rel.Batch {
+			continue
+		}
+		r := batchRange{}
+		if rel.Episode != nil && rel.EpisodeEnd != nil {
+			r = batchRange{*rel.Episode, *rel.EpisodeEnd}
+			upTo = max(upTo, r.last)
+		}
+		ranges[i] = r
+	}
+
+	missing := scanner.MissingEpisodes(have, upTo)
+
+	var best *models.RSSMatch
+	bestCover := 0
+	for i := range matches {
+		r, ok := ranges[i]
+		if !ok {
+			continue
+		}
+		cover := 0
+		switch {
+		case r.last > 0:
+			for _, ep := range missing {
+				if ep >= r.first && ep <= r.last {
+					cover++
+				}
+			}
+		case upTo > 0:
+			cover = len(missing)
+		case len(have) == 0:
+			cover = 1 // nothing known either way; an empty season wants it
+		}
+		// Matches are sorted best first, so the first of equal coverage wins
+		if cover > bestCover {
+			best, bestCover = &matches[i], cover
+		}
+	}
+	return best
+}
@@ -0,0 +1,110 @@
+package rss
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"link-anime/internal/models"
+)
+
+// serveFeed serves an RSS feed of the titles, each with its own magnet, and
+// returns its URL.
+func serveFeed(t *testing.T, titles ...string) string {
+	t.Helper()
+	var items strings.Builder
+	for i, title := range titles {
+		fmt.Fprintf(&items, `<item><title>%s</title><enclosure url="magnet:?xt=urn:btih:%040x" type="application/x-bittorrent"/></item>`,
+			html.EscapeString(title), i+1)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<rss version="2.0"><channel>%s</channel></rss>`, items.String())
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestPickBatch(t *testing.T) {
+	episode := func(n int) models.RSSMatch {
+		return models.RSSMatch{Title: fmt.Sprintf("[Grp] Show - %02d [1080p]", n), Episode: &n}
+	}
+	batch := func(title string) models.RSSMatch { return models.RSSMatch{Title: title} }
+	have := func(eps ...int) map[int]bool {
+		m := map[int]bool{}
+		for _, ep := range eps {
+			m[ep] = true
+		}
+		return m
+	}
+
+	tests := []struct {
+		name    string
+		matches []models.RSSMatch
+		have    map[int]bool
+		want    string // "" for none
+	}{
+		{"range covering the missing episodes",
+			[]models.RSSMatch{batch("[A] Show - 01-12 [1080p]"), batch("[B] Show - 13-24 [1080p]")},
+			have(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12), "[B] Show - 13-24 [1080p]"},
+		{"range already in the library",
+			[]models.RSSMatch{batch("[A] Show - 01-12 [1080p]")},
+			have(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12), ""},
+		{"open-ended batch covers everything missing",
+			[]models.RSSMatch{episode(5), batch("[A] Show - 01-02 [1080p]"), batch("[B] Show (Batch) [1080p]")},
+			have(1, 2), "[B] Show (Batch) [1080p]"},
+		{"open-ended batch, no episode numbers, empty library",
+			[]models.RSSMatch{batch("[A] Show (Batch) [1080p]"), batch("[B] Show S01 [1080p] [Batch]")},
+			have(), "[A] Show (Batch) [1080p]"},
+		{"open-ended batch, no episode numbers, library has some",
+			[]models.RSSMatch{batch("[A] Show (Batch) [1080p]")},
+			have(1), ""},
+		{"no batches",
+			[]models.RSSMatch{episode(1), episode(2)},
+			have(), ""},
+	}
+	for _, tt := range tests {
+		got := pickBatch(tt.matches, tt.have)
+		title := ""
+		if got != nil {
+			title = got.Title
+		}
+		if title != tt.want {
+			t.Errorf("%s: pickBatch = %q, want %q", tt.name, title, tt.want)
+		}
+	}
+}
+
+func TestBackfillNoneSkipsEverything(t *testing.T) {
+	p, rule := retryFixture(t)
+	media := t.TempDir()
+	p.dirs = func() (string, string, string) { return "", media, "" }
+	rule.SourceType = SourceURL
+	rule.FeedURL = serveFeed(t, "[Grp] Show - 01 [1080p]", "[Grp] Show - 02 [1080p]", "[Grp] Show - 01-12 [1080p]")
+
+	res, err := p.Backfill(rule, BackfillNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Grabbed) != 0 || res.Skipped != 3 || res.InLibrary != 0 {
+		t.Errorf("result = %+v, want 3 skipped and nothing grabbed", res)
+	}
+	matches, err := ListMatches(rule.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("recorded %d matches, want 3", len(matches))
+	}
+	for _, m := range matches {
+		if m.Status != decisionSkip {
+			t.Errorf("%s recorded as %s, want %s", m.Title, m.Status, decisionSkip)
+		}
+	}
+
+	if res, err = p.Backfill(rule, BackfillNone); err != nil || res.Skipped != 0 {
+		t.Errorf("second backfill: %+v, %v; want nothing new skipped", res, err)
+	}
+}
@@ -0,0 +1,146 @@
+package rss
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"link-anime/internal/ical"
+	"link-anime/internal/linker"
+	"link-anime/internal/models"
+)
+
+const (
+	calendarWeeksAhead = 4                // expected episodes listed per rule
+	calendarEventSpan  = 30 * time.Minute // duration shown for each event
+)
+
+// grabEvent is a grabbed release as shown on the calendar.
+type grabEvent struct {
+	id              int64
+	title, status   string
+	matched         time.Time
+	season, episode *int
+	showName        string
+	ruleName        string
+}
+
+// CalendarEvents returns the expected episodes of active rules for the next
+// few weeks, plus grabs and library links from the last pastDays days.
+func CalendarEvents(pastDays int) ([]ical.Event, error) {
+	rules, err := ListRules()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var events []ical.Event
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		entry, ok, err := scheduleEntry(rule, now)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			events = append(events, expectedEvents(rule, entry)...)
+		}
+	}
+
+	grabs, err := recentGrabs(pastDays)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range grabs {
+		events = append(events, ical.Event{
+			UID:         fmt.Sprintf("match-%d@link-anime", g.id),
+			Start:       g.matched,
+			End:         g.matched.Add(calendarEventSpan),
+			Summary:     eventSummary(g.showName, episodeLabel(g.season, g.episode), g.status),
+			Description: fmt.Sprintf("Rule: %s\nRelease: %s\nStatus: %s", g.ruleName, g.title, g.status),
+			Status:      "CONFIRMED",
+			Categories:  []string{"grab", g.status},
+		})
+	}
+
+	history, err := linker.GetHistory(500)
+	if err != nil {
+		return nil, err
+	}
+	since := now.AddDate(0, 0, -pastDays)
+	for _, h := range history {
+		if h.Timestamp.Before(since) {
+			continue
+		}
+		events = append(events, ical.Event{
+			UID:         fmt.Sprintf("link-%d@link-anime", h.ID),
+			Start:       h.Timestamp,
+			End:         h.Timestamp.Add(calendarEventSpan),
+			Summary:     eventSummary(h.ShowName, episodeLabel(h.Season, nil), "linked"),
+			Description: fmt.Sprintf("%d files linked to %s\nSource: %s", h.FileCount, h.DestPath, h.Source),
+			Status:      "CONFIRMED",
+			Categories:  []string{"linked"},
+		})
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	return events, nil
+}
+
+// expectedEvents projects a rule's schedule over the coming weeks.
+func expectedEvents(rule models.RSSRule, entry models.ScheduleEntry) []ical.Event {
+	var events []ical.Event
+	for week := 0; week < calendarWeeksAhead; week++ {
+		start := entry.NextAir.AddDate(0, 0, 7*week)
+		var episode *int
+		if entry.Episode != nil {
+			n := *entry.Episode + week
+			if rule.ExpectedEpisodes > 0 && n > rule.ExpectedEpisodes {
+				break
+			}
+			episode = &n
+		}
+
+		status := "expected"
+		if week == 0 && entry.Missed {
+			status = "missed"
+		}
+		season := entry.Season
+		events = append(events, ical.Event{
+			UID:         fmt.Sprintf("expected-%d-%s@link-anime", rule.ID, start.Format("20060102")),
+			Start:       start,
+			End:         start.Add(calendarEventSpan),
+			Summary:     eventSummary(rule.ShowName, episodeLabel(&season, episode), status),
+			Description: fmt.Sprintf("Rule: %s\nSchedule: %ss %s UTC (%s)\nStatus: %s", rule.Name, time.Weekday(entry.AirDay), entry.AirTime, entry.Source, status),
+			Status:      "TENTATIVE",
+			Categories:  []string{status},
+		})
+	}
+	return events
+}
+
+// eventSummary joins the non-empty parts of an event title.
+func eventSummary(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// episodeLabel formats "S01E05", "S01" or "" from whatever is known.
+func episodeLabel(season, episode *int) string {
+	switch {
+	case season != nil && episode != nil:
+		return fmt.Sprintf("S%02dE%02d", *season, *episode)
+	case season != nil:
+		return fmt.Sprintf("S%02d", *season)
+	case episode != nil:
+		return fmt.Sprintf("E%02d", *episode)
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package rss
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"link-anime/internal/models"
+	"link-anime/internal/notify"
+	"link-anime/internal/scanner"
+)
+
+// completionGrace is how long a rule keeps polling after its end date, or
+// after its last match if that came later, to catch late batches and fixes.
+const completionGrace = 7 * 24 * time.Hour
+
+// checkCompletion completes and disables a rule whose season is done,
+// reporting whether it did.
+func (p *Poller) checkCompletion(rule models.RSSRule) bool {
+	reason := p.completionReason(rule)
+	if reason == "" {
+		return false
+	}
+
+	if err := completeRule(rule.ID, reason); err != nil {
+		log.Printf("RSS poll [%s]: %v", rule.Name, err)
+		return false
+	}
+	log.Printf("RSS rule complete [%s]: %s", rule.Name, reason)
+
+	p.hub.Broadcast(models.WSMessage{
+		Type: "rss_rule_complete",
+		Data: map[string]interface{}{
+			"ruleId":   rule.ID,
+			"ruleName": rule.Name,
+			"reason":   reason,
+		},
+	})
+
+	if n := p.notifier(); n != nil {
+		n.Send("RSS Rule Complete", fmt.Sprintf("%s is complete and has been disabled", rule.Name), []notify.Field{
+			{Name: "Show", Value: rule.ShowName},
+			{Name: "Season", Value: strconv.Itoa(rule.Season)},
+			{Name: "Reason", Value: reason},
+		}, "green")
+	}
+	return true
+}
+
+// completionReason returns why a rule is complete, or "" if it isn't.
+func (p *Poller) completionReason(rule models.RSSRule) string {
+	if rule.ExpectedEpisodes > 0 && rule.MediaType != "movie" {
+		_, mediaDir, _ := p.dirs()
+		have := scanner.LibraryEpisodes(mediaDir, rule.ShowName, rule.Season)
+		if len(scanner.MissingEpisodes(have, rule.ExpectedEpisodes)) == 0 {
+			return fmt.Sprintf("all %d episodes are in the library", rule.ExpectedEpisodes)
+		}
+	}
+
+	if rule.EndDate != nil && time.Now().After(*rule.EndDate) {
+		since := *rule.EndDate
+		if last, err := lastGrab(rule.ID); err != nil {
+			log.Printf("RSS poll [%s]: %v", rule.Name, err)
+			return ""
+		} else if last != nil && last.After(since) {
+			since = *last
+		}
+		if time.Since(since) >= completionGrace {
+			return "end date passed with no new matches"
+		}
+	}
+
+	return ""
+}
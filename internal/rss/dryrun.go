@@ -0,0 +1,104 @@
+package rss
+
+import (
+	"fmt"
+
+	"link-anime/internal/models"
+	"link-anime/internal/parser"
+)
+
+// Dry run results.
+const (
+	TestWouldGrab      = "would-grab"
+	TestAlreadyMatched = "already-matched"
+	TestRejected       = "rejected"
+)
+
+// TestRule runs a rule, saved or not, against its live feed with the same
+// fetch, filters and dedupe as the poller, without grabbing or recording
+// anything. Items are returned in feed order.
+func TestRule(rule models.RSSRule) ([]models.RSSTestItem, error) {
+	filter, err := newRuleFilter(rule)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := ruleProfile(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := fetchSource(rule)
+	if err != nil {
+		return nil, fmt.Errorf("fetch feed: %w", err)
+	}
+
+	items := make([]models.RSSTestItem, len(results))
+	byHash := make(map[string][]int) // dedupe key -> item indexes
+	var passed []models.NyaaResult
+
+	for i, result := range results {
+		rel := parser.ParseRelease(result.Title)
+		match := newMatch(rule, result, rel, releaseScore(profile, rel))
+		items[i] = models.RSSTestItem{
+			Title:      result.Title,
+			Size:       result.Size,
+			Seeders:    result.Seeders,
+			Score:      match.Score,
+			Season:     match.Season,
+			Episode:    match.Episode,
+			Resolution: rel.Resolution,
+			Group:      rel.Group,
+		}
+
+		if reason := filter.reject(result, rel); reason != "" {
+			items[i].Result = TestRejected
+			items[i].Reason = reason
+			continue
+		}
+		byHash[match.Hash] = append(byHash[match.Hash], i)
+		passed = append(passed, result)
+	}
+
+	// Decide best first, as the poller does, remembering what this run would take
+	taken := make(map[[2]int]string) // season/episode -> title
+	seen := make(map[string]bool)
+	for _, match := range candidates(rule, profile, filter, passed) {
+		if seen[match.Hash] {
+			continue
+		}
+		seen[match.Hash] = true
+
+		result, reason := TestWouldGrab, ""
+		var key [2]int
+		if match.Episode != nil {
+			key = [2]int{*match.Season, *match.Episode}
+		}
+
+		if title, ok := taken[key]; ok && match.Episode != nil {
+			result, reason = TestRejected, fmt.Sprintf("a better release of this episode is preferred: %s", title)
+		} else {
+			decision, why, err := decide(rule, profile, &match)
+			if err != nil {
+				return nil, err
+			}
+			switch decision {
+			case decisionDuplicate:
+				result, reason = TestAlreadyMatched, why
+			case decisionSkip, decisionReject:
+				result, reason = TestRejected, why
+			default:
+				reason = why
+			}
+			if result == TestWouldGrab && match.Episode != nil {
+				taken[key] = match.Title
+			}
+		}
+
+		for _, i := range byHash[match.Hash] {
+			items[i].Result = result
+			items[i].Reason = reason
+		}
+	}
+
+	return items, nil
+}
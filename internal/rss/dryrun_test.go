@@ -0,0 +1,49 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTestRule(t *testing.T) {
+	_, rule := retryFixture(t)
+	rule.SourceType = SourceURL
+	rule.ExcludePattern = "480p"
+	rule.FeedURL = serveFeed(t,
+		"[Grp] Show - 01 [1080p]",
+		"[Grp] Show - 02 [720p]",
+		"[Grp] Show - 02 [1080p]",
+		"[Grp] Show - 03 [480p]",
+		"[Grp] Show - 04 [1080p]",
+	)
+	insertTestMatch(t, rule.ID, "[Grp] Show - 01 [1080p]", "downloaded", "")
+
+	items, err := TestRule(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ result, reason string }{
+		{TestAlreadyMatched, "already matched"},
+		{TestRejected, "a better release of this episode is preferred: [Grp] Show - 02 [1080p]"},
+		{TestWouldGrab, ""},
+		{TestRejected, "matches exclude pattern"},
+		{TestWouldGrab, ""},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i, w := range want {
+		if items[i].Result != w.result || !strings.Contains(items[i].Reason, w.reason) {
+			t.Errorf("%s: %s (%q), want %s (%q)", items[i].Title, items[i].Result, items[i].Reason, w.result, w.reason)
+		}
+	}
+
+	// A dry run records nothing
+	matches, err := ListMatches(rule.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("dry run left %d matches, want only the existing one", len(matches))
+	}
+}
@@ -0,0 +1,187 @@
+package rss
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"link-anime/internal/indexer"
+	"link-anime/internal/models"
+	"link-anime/internal/nyaa"
+	"link-anime/internal/parser"
+	"link-anime/internal/quality"
+)
+
+// ruleFilter holds a rule with its patterns compiled.
+type ruleFilter struct {
+	rule    models.RSSRule
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// newRuleFilter compiles a rule's patterns. Patterns are case-insensitive.
+func newRuleFilter(rule models.RSSRule) (*ruleFilter, error) {
+	f := &ruleFilter{rule: rule}
+
+	var err error
+	if rule.IncludePattern != "" {
+		if f.include, err = regexp.Compile("(?i)" + rule.IncludePattern); err != nil {
+			return nil, fmt.Errorf("invalid include pattern: %w", err)
+		}
+	}
+	if rule.ExcludePattern != "" {
+		if f.exclude, err = regexp.Compile("(?i)" + rule.ExcludePattern); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+		}
+	}
+
+	return f, nil
+}
+
+// ValidateRule checks a rule's source and filters before it is saved,
+// filling in source defaults.
+func ValidateRule(rule *models.RSSRule) error {
+	if err := validateSource(rule); err != nil {
+		return err
+	}
+	if rule.UpgradePolicy == "" {
+		rule.UpgradePolicy = UpgradeNone
+	}
+	if !validUpgradePolicy(rule.UpgradePolicy) {
+		return fmt.Errorf("unknown upgradePolicy %q", rule.UpgradePolicy)
+	}
+	if rule.ProfileID != 0 {
+		if profile, err := quality.Get(rule.ProfileID); err != nil {
+			return err
+		} else if profile == nil {
+			return fmt.Errorf("quality profile %d not found", rule.ProfileID)
+		}
+	}
+	if _, err := newRuleFilter(*rule); err != nil {
+		return err
+	}
+	if err := validateAirSchedule(rule); err != nil {
+		return err
+	}
+	if rule.PollIntervalMinutes < 0 {
+		return fmt.Errorf("pollIntervalMinutes must not be negative")
+	}
+	if rule.ExpectedEpisodes < 0 {
+		return fmt.Errorf("expectedEpisodes must not be negative")
+	}
+	if rule.MinSize < 0 || rule.MaxSize < 0 {
+		return fmt.Errorf("size limits must not be negative")
+	}
+	if rule.MaxSize > 0 && rule.MinSize > rule.MaxSize {
+		return fmt.Errorf("minSize is larger than maxSize")
+	}
+	return nil
+}
+
+func validateSource(rule *models.RSSRule) error {
+	if rule.SourceType == "" {
+		rule.SourceType = SourceNyaa
+	}
+	if rule.NyaaFilter == "" {
+		rule.NyaaFilter = "trusted"
+	}
+
+	switch rule.SourceType {
+	case SourceNyaa:
+		if rule.Query == "" {
+			return fmt.Errorf("query is required for nyaa search rules")
+		}
+	case SourceNyaaUser:
+		if rule.FeedUser == "" {
+			return fmt.Errorf("feedUser is required for nyaa_user rules")
+		}
+	case SourceURL:
+		u, err := url.Parse(rule.FeedURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("feedUrl must be an http(s) URL")
+		}
+	case SourceIndexers:
+		if rule.Query == "" {
+			return fmt.Errorf("query is required for indexers rules")
+		}
+		for _, id := range rule.Indexers {
+			if cfg, err := indexer.Get(id); err != nil {
+				return err
+			} else if cfg == nil {
+				return fmt.Errorf("indexer %d not found", id)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown sourceType %q", rule.SourceType)
+	}
+
+	switch rule.NyaaFilter {
+	case "trusted", "noremakes", "all":
+	default:
+		return fmt.Errorf("unknown nyaaFilter %q", rule.NyaaFilter)
+	}
+	if rule.NyaaCategory != "" {
+		if _, ok := nyaa.Categories[rule.NyaaCategory]; !ok {
+			return fmt.Errorf("unknown nyaaCategory %q", rule.NyaaCategory)
+		}
+	}
+	return nil
+}
+
+// reject returns why a result fails the rule's filters, or "" if it passes.
+func (f *ruleFilter) reject(result models.NyaaResult, rel parser.Release) string {
+	rule := f.rule
+
+	if rule.MinSeeders > 0 && result.Seeders < rule.MinSeeders {
+		return fmt.Sprintf("seeders %d below %d", result.Seeders, rule.MinSeeders)
+	}
+
+	if rule.Resolution != "" && !strings.Contains(strings.ToLower(result.Title), strings.ToLower(rule.Resolution)) {
+		return fmt.Sprintf("resolution is not %s", rule.Resolution)
+	}
+
+	if f.include != nil && !f.include.MatchString(result.Title) {
+		return "does not match include pattern"
+	}
+	if f.exclude != nil && f.exclude.MatchString(result.Title) {
+		return "matches exclude pattern"
+	}
+
+	if len(rule.Groups) > 0 && !containsFold(rule.Groups, rel.Group) {
+		return fmt.Sprintf("group %q not in allowlist", rel.Group)
+	}
+	if rel.Group != "" && containsFold(rule.BlockedGroups, rel.Group) {
+		return fmt.Sprintf("group %q is blocked", rel.Group)
+	}
+
+	if rule.MinSize > 0 || rule.MaxSize > 0 {
+		if result.SizeBytes == 0 {
+			return "size unknown"
+		}
+		if rule.MinSize > 0 && result.SizeBytes < rule.MinSize {
+			return fmt.Sprintf("size %s below minimum", result.Size)
+		}
+		if rule.MaxSize > 0 && result.SizeBytes > rule.MaxSize {
+			return fmt.Sprintf("size %s above maximum", result.Size)
+		}
+	}
+
+	if len(rule.Codecs) > 0 && !containsFold(rule.Codecs, rel.Codec) {
+		return fmt.Sprintf("codec %q not allowed", rel.Codec)
+	}
+	if len(rule.Sources) > 0 && !containsFold(rule.Sources, rel.Source) {
+		return fmt.Sprintf("source %q not allowed", rel.Source)
+	}
+
+	return ""
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(strings.TrimSpace(v), s) {
+			return true
+		}
+	}
+	return false
+}
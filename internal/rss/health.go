@@ -0,0 +1,71 @@
+package rss
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"link-anime/internal/models"
+	"link-anime/internal/notify"
+)
+
+// failureAlertAfter is how many checks in a row must fail before the rule
+// is reported as broken.
+const failureAlertAfter = 3
+
+// checkFailed records a failed check, backs the rule off and alerts once the
+// failures reach failureAlertAfter.
+func (p *Poller) checkFailed(rule models.RSSRule, err error) {
+	failures := rule.ConsecutiveFailures + 1
+	delay := backoffDelay(ruleInterval(rule), failures)
+	log.Printf("RSS poll [%s]: %v (%d in a row, retrying in %s)",
+		rule.Name, err, failures, delay.Round(time.Minute))
+	recordFailure(rule.ID, failures, err.Error(), time.Now().Add(jittered(delay)))
+
+	if failures != failureAlertAfter {
+		return
+	}
+
+	p.hub.Broadcast(models.WSMessage{
+		Type: "rss_rule_error",
+		Data: map[string]interface{}{
+			"ruleId":   rule.ID,
+			"ruleName": rule.Name,
+			"error":    err.Error(),
+			"failures": failures,
+		},
+	})
+
+	if n := p.notifier(); n != nil {
+		n.Send("RSS Rule Failing", fmt.Sprintf("%s has failed %d checks in a row", rule.Name, failures), []notify.Field{
+			{Name: "Error", Value: err.Error()},
+			{Name: "Next retry", Value: delay.Round(time.Minute).String()},
+		}, "red")
+	}
+}
+
+// checkSucceeded records a successful check and schedules the next one,
+// announcing recovery for a rule that had been reported as broken.
+func (p *Poller) checkSucceeded(rule models.RSSRule) {
+	recordCheck(rule.ID, time.Now().Add(jittered(ruleInterval(rule))))
+
+	if rule.ConsecutiveFailures < failureAlertAfter {
+		return
+	}
+	log.Printf("RSS poll [%s]: recovered after %d failed checks", rule.Name, rule.ConsecutiveFailures)
+
+	p.hub.Broadcast(models.WSMessage{
+		Type: "rss_rule_recovered",
+		Data: map[string]interface{}{
+			"ruleId":   rule.ID,
+			"ruleName": rule.Name,
+		},
+	})
+
+	if n := p.notifier(); n != nil {
+		n.Send("RSS Rule Recovered", fmt.Sprintf("%s is working again", rule.Name), []notify.Field{
+			{Name: "Failed checks", Value: strconv.Itoa(rule.ConsecutiveFailures)},
+		}, "green")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
@@ -12,7 +13,7 @@ import (
 
 	"link-anime/internal/database"
 	"link-anime/internal/models"
-	"link-anime/internal/nyaa"
+	"link-anime/internal/notify"
 	"link-anime/internal/qbit"
 	"link-anime/internal/ws"
 )
@@ -21,25 +22,38 @@ import (
 // This allows the poller to pick up client changes from reinitClients.
 type QbitGetter func() *qbit.Client
 
-// Poller periodically checks Nyaa RSS feeds for new matches.
+// SpaceChecker reports whether there is enough free disk space to add torrents.
+type SpaceChecker func() bool
+
+// Poller periodically checks each rule's feed for new matches.
 type Poller struct {
 	hub      *ws.Hub
 	getQbit  QbitGetter
-	interval time.Duration
+	category func() string // global qBittorrent category
+	notifier func() *notify.Notifier
+	dirs     DirsGetter
+	hasSpace SpaceChecker
+	limiter  *tokenBucket // paces feed requests across rules
 	stopCh   chan struct{}
 	mu       sync.Mutex
 	running  bool
+	cycleMu  sync.Mutex // serializes poll cycles and backfills
+	retryMu  sync.Mutex // serializes retries of pending and failed matches
 }
 
-// NewPoller creates a new RSS poller.
-func NewPoller(hub *ws.Hub, getQbit QbitGetter, interval time.Duration) *Poller {
-	if interval < 5*time.Minute {
-		interval = 15 * time.Minute
-	}
+// NewPoller creates a new RSS poller. category, notifier and dirs are
+// functions so they pick up reinitClients() and settings changes.
+// hasSpace may be nil, in which case disk space is not checked.
+// The poll interval is read from settings on every cycle (see PollInterval).
+func NewPoller(hub *ws.Hub, getQbit QbitGetter, category func() string, notifier func() *notify.Notifier, dirs DirsGetter, hasSpace SpaceChecker) *Poller {
 	return &Poller{
 		hub:      hub,
 		getQbit:  getQbit,
-		interval: interval,
+		category: category,
+		notifier: notifier,
+		dirs:     dirs,
+		hasSpace: hasSpace,
+		limiter:  newTokenBucket(feedBurst, feedRefill),
 		stopCh:   make(chan struct{}),
 	}
 }
@@ -54,19 +68,20 @@ func (p *Poller) Start() {
 	p.running = true
 	p.mu.Unlock()
 
-	log.Printf("RSS poller started (interval: %s)", p.interval)
+	log.Printf("RSS poller started (default interval: %s)", PollInterval())
 
 	go func() {
 		// Run immediately on start
-		p.poll()
+		p.poll(false)
 
-		ticker := time.NewTicker(p.interval)
+		// Rules have their own schedules; wake often and check those that are due
+		ticker := time.NewTicker(schedulerTick)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ticker.C:
-				p.poll()
+				p.poll(false)
 			case <-p.stopCh:
 				log.Println("RSS poller stopped")
 				return
@@ -85,97 +100,298 @@ func (p *Poller) Stop() {
 	}
 }
 
-// PollNow triggers an immediate poll cycle (for manual refresh).
+// PollNow triggers an immediate poll of every enabled rule, due or not
+// (for manual refresh). Feed requests are still rate limited.
 func (p *Poller) PollNow() {
-	go p.poll()
+	go p.poll(true)
 }
 
-// poll runs one cycle: fetch each enabled rule, check for new matches.
-func (p *Poller) poll() {
+// poll runs one cycle: fetch each enabled rule that is due (every enabled
+// rule when force is set) and check for new matches.
+func (p *Poller) poll(force bool) {
+	p.cycleMu.Lock()
+	defer p.cycleMu.Unlock()
+
 	rules, err := ListRules()
 	if err != nil {
 		log.Printf("RSS poll: failed to list rules: %v", err)
 		return
 	}
 
+	// Retry earlier grabs first; they were chosen before anything found now
+	p.retryMatches()
+
+	// Rules reading the same feed share one fetch per cycle
+	cache := feedCache{}
+	now := time.Now()
 	for _, rule := range rules {
-		if !rule.Enabled {
+		if !rule.Enabled || (!force && !isDue(rule, now)) {
 			continue
 		}
-		p.checkRule(rule)
+		if !cache.has(rule) && !p.limiter.wait(p.stopCh) {
+			return
+		}
+		p.checkRule(rule, cache)
 	}
 }
 
-// checkRule fetches the Nyaa RSS feed for a rule and processes matches.
-func (p *Poller) checkRule(rule models.RSSRule) {
-	results, err := nyaa.Search(rule.Query, "trusted")
+// checkRule fetches the feed for a rule and processes matches.
+func (p *Poller) checkRule(rule models.RSSRule, cache feedCache) {
+	// Checked before fetching so a finished show completes even if its feed is gone
+	if p.checkCompletion(rule) {
+		return
+	}
+
+	filter, err := newRuleFilter(rule)
 	if err != nil {
-		log.Printf("RSS poll [%s]: search failed: %v", rule.Name, err)
+		p.checkFailed(rule, err)
 		return
 	}
 
-	for _, result := range results {
-		// Apply filters
-		if rule.MinSeeders > 0 && result.Seeders < rule.MinSeeders {
-			continue
-		}
+	profile, err := ruleProfile(rule)
+	if err != nil {
+		p.checkFailed(rule, err)
+		return
+	}
 
-		if rule.Resolution != "" && !strings.Contains(strings.ToLower(result.Title), strings.ToLower(rule.Resolution)) {
-			continue
-		}
+	results, err := cache.fetch(rule)
+	if err != nil {
+		p.checkFailed(rule, fmt.Errorf("fetch failed: %w", err))
+		return
+	}
 
-		// Generate a hash for deduplication (use title hash since Nyaa RSS doesn't give info hashes directly)
-		hash := hashTitle(result.Title)
+	for _, match := range candidates(rule, profile, filter, results) {
+		p.process(rule, profile, &match)
+	}
 
-		// Check if we already matched this
-		if isAlreadyMatched(rule.ID, hash) {
-			continue
-		}
+	p.resolveCandidates(rule, profile)
 
-		// New match found
-		log.Printf("RSS match [%s]: %s", rule.Name, result.Title)
+	p.checkSucceeded(rule)
 
-		// Try to add to qBittorrent if configured
-		status := "downloaded"
-		qbitClient := p.getQbit()
-		if qbitClient != nil && qbitClient.IsConfigured() {
-			if err := qbitClient.AddMagnet(result.Magnet, "", ""); err != nil {
-				log.Printf("RSS poll [%s]: failed to add torrent: %v", rule.Name, err)
-				status = "failed"
-			}
+	p.checkMissed(rule)
+}
+
+// process decides on a filtered release, grabs it if chosen, and records the
+// decision. It reports whether the release was grabbed.
+func (p *Poller) process(rule models.RSSRule, profile *models.QualityProfile, match *models.RSSMatch) bool {
+	decision, reason, err := decide(rule, profile, match)
+	if err != nil {
+		log.Printf("RSS poll [%s]: %v", rule.Name, err)
+		return false
+	}
+
+	switch decision {
+	case decisionDuplicate:
+		return false
+	case decisionGrab:
+		match.Reason = reason
+		p.grab(rule, match)
+	default:
+		log.Printf("RSS %s [%s]: %s (%s)", decision, rule.Name, match.Title, reason)
+		match.Status = decision
+		match.Reason = reason
+	}
+
+	// Record the match
+	if err := InsertMatch(match); err != nil {
+		log.Printf("RSS poll [%s]: failed to record match: %v", rule.Name, err)
+	}
+	if decision == decisionGrab {
+		p.broadcastMatch(rule, *match)
+		return true
+	}
+	return false
+}
+
+// resolveCandidates grabs the best delayed candidate of each episode whose
+// delay window has passed, and marks the others skipped.
+func (p *Poller) resolveCandidates(rule models.RSSRule, profile *models.QualityProfile) {
+	var delay time.Duration
+	if profile != nil {
+		delay = time.Duration(profile.DelayMinutes) * time.Minute
+	}
+
+	due, err := dueCandidates(rule.ID, delay)
+	if err != nil {
+		log.Printf("RSS poll [%s]: %v", rule.Name, err)
+		return
+	}
+
+	var best *models.RSSMatch
+	for i := range due {
+		match := &due[i]
+		// Rows are ordered best first within each episode
+		if best == nil || *best.Season != *match.Season || *best.Episode != *match.Episode {
+			best = match
+			match.Reason = fmt.Sprintf("best of delayed releases (score %d)", match.Score)
+			p.grab(rule, match)
+			p.broadcastMatch(rule, *match)
 		} else {
-			log.Printf("RSS poll [%s]: qBittorrent not configured, recording match only", rule.Name)
-			status = "pending"
+			match.Status = decisionSkip
+			match.Reason = fmt.Sprintf("lower score than %s", best.Title)
+			log.Printf("RSS skipped [%s]: %s (%s)", rule.Name, match.Title, match.Reason)
 		}
-
-		// Record the match
-		if err := InsertMatch(rule.ID, result.Title, hash, status); err != nil {
-			log.Printf("RSS poll [%s]: failed to record match: %v", rule.Name, err)
+		if err := updateMatchDecision(match); err != nil {
+			log.Printf("RSS poll [%s]: failed to record decision: %v", rule.Name, err)
 		}
+	}
+}
+
+// grab adds a match's torrent to qBittorrent and sets its status. A match
+// that can't be sent now is left "pending" or "failed", with the cause as its
+// reason, for the retry queue.
+func (p *Poller) grab(rule models.RSSRule, match *models.RSSMatch) {
+	if match.UpgradeOf != nil {
+		log.Printf("RSS upgrade [%s]: %s", rule.Name, match.Title)
+	} else {
+		log.Printf("RSS match [%s]: %s", rule.Name, match.Title)
+	}
+
+	recordLastMatch(rule.ID)
+
+	status, cause := p.send(rule, match.Magnet)
+	match.Status = status
+	if cause != "" {
+		log.Printf("RSS poll [%s]: %s, recording match only", rule.Name, cause)
+		match.Reason = cause
+	}
+}
+
+// send adds a torrent to qBittorrent if configured. It returns the match
+// status, and why the torrent wasn't sent if it wasn't.
+func (p *Poller) send(rule models.RSSRule, magnet string) (string, string) {
+	qbitClient := p.getQbit()
+	switch {
+	case p.hasSpace != nil && !p.hasSpace():
+		return "pending", "low disk space"
+	case qbitClient == nil || !qbitClient.IsConfigured():
+		return "pending", "qBittorrent not configured"
+	}
+	if err := qbitClient.AddTorrent(magnet, p.addOptions(rule)); err != nil {
+		return "failed", fmt.Sprintf("failed to add torrent: %v", err)
+	}
+	return "downloaded", ""
+}
 
-		// Broadcast via WebSocket
-		p.hub.Broadcast(models.WSMessage{
-			Type: "rss_match",
-			Data: map[string]interface{}{
-				"ruleName": rule.Name,
-				"title":    result.Title,
-				"status":   status,
-			},
-		})
+// addOptions returns the qBittorrent options for a rule's grabs.
+func (p *Poller) addOptions(rule models.RSSRule) qbit.AddOptions {
+	opts := qbit.AddOptions{
+		Category:       rule.Category,
+		SavePath:       rule.SavePath,
+		Paused:         rule.AddPaused,
+		Sequential:     rule.Sequential,
+		FirstLastPiece: rule.FirstLastPiece,
+	}
+	if opts.Category == "" {
+		opts.Category = p.category()
 	}
 
-	// Update last_check timestamp
-	updateLastCheck(rule.ID)
+	tags := rule.Tags
+	if len(tags) == 0 {
+		tags = []string{"link-anime", rule.Name}
+	}
+	for _, tag := range tags {
+		// qBittorrent separates tags with commas
+		if tag = strings.TrimSpace(strings.ReplaceAll(tag, ",", " ")); tag != "" {
+			opts.Tags = append(opts.Tags, tag)
+		}
+	}
+	return opts
+}
+
+func (p *Poller) broadcastMatch(rule models.RSSRule, match models.RSSMatch) {
+	p.hub.Broadcast(models.WSMessage{
+		Type: "rss_match",
+		Data: map[string]interface{}{
+			"ruleName": rule.Name,
+			"title":    match.Title,
+			"status":   match.Status,
+			"score":    match.Score,
+			"upgrade":  match.UpgradeOf != nil,
+		},
+	})
 }
 
 // --- Database helpers ---
 
+// ruleColumns is the column list read by scanRule, in order.
+const ruleColumns = `
+	r.id, r.name, r.query, r.show_name, r.season, r.media_type,
+	r.min_seeders, r.resolution,
+	r.source_type, r.nyaa_category, r.nyaa_filter, r.feed_user, r.feed_url, r.indexers,
+	r.include_pattern, r.exclude_pattern,
+	r.groups, r.blocked_groups, r.min_size, r.max_size, r.codecs, r.sources,
+	r.upgrade_policy, r.auto_link, r.profile_id,
+	r.category, r.tags, r.save_path, r.add_paused, r.sequential, r.first_last_piece,
+	r.expected_episodes, r.end_date, r.completed_at, r.completed_reason,
+	r.poll_interval_minutes, r.next_check, r.consecutive_failures,
+	r.last_error, r.last_success, r.last_match,
+	r.air_day, r.air_time, r.missed_alert_at,
+	r.enabled, r.last_check, r.created_at,
+	(SELECT COUNT(*) FROM rss_matches WHERE rule_id = r.id) as match_count`
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRule(row rowScanner) (models.RSSRule, error) {
+	var r models.RSSRule
+	var lastCheck, endDate, completedAt, nextCheck, lastSuccess, lastMatch, missedAlertAt sql.NullTime
+	var airDay sql.NullInt64
+	var groups, blockedGroups, codecs, sources, tags, indexers string
+	err := row.Scan(&r.ID, &r.Name, &r.Query, &r.ShowName, &r.Season,
+		&r.MediaType, &r.MinSeeders, &r.Resolution,
+		&r.SourceType, &r.NyaaCategory, &r.NyaaFilter, &r.FeedUser, &r.FeedURL, &indexers,
+		&r.IncludePattern, &r.ExcludePattern,
+		&groups, &blockedGroups, &r.MinSize, &r.MaxSize, &codecs, &sources,
+		&r.UpgradePolicy, &r.AutoLink, &r.ProfileID,
+		&r.Category, &tags, &r.SavePath, &r.AddPaused, &r.Sequential, &r.FirstLastPiece,
+		&r.ExpectedEpisodes, &endDate, &completedAt, &r.CompletedReason,
+		&r.PollIntervalMinutes, &nextCheck, &r.ConsecutiveFailures,
+		&r.LastError, &lastSuccess, &lastMatch,
+		&airDay, &r.AirTime, &missedAlertAt,
+		&r.Enabled, &lastCheck, &r.CreatedAt, &r.MatchCount)
+	if err != nil {
+		return r, err
+	}
+	if lastCheck.Valid {
+		r.LastCheck = &lastCheck.Time
+	}
+	if endDate.Valid {
+		r.EndDate = &endDate.Time
+	}
+	if completedAt.Valid {
+		r.CompletedAt = &completedAt.Time
+	}
+	if nextCheck.Valid {
+		r.NextCheck = &nextCheck.Time
+	}
+	if lastSuccess.Valid {
+		r.LastSuccess = &lastSuccess.Time
+	}
+	if lastMatch.Valid {
+		r.LastMatch = &lastMatch.Time
+	}
+	if airDay.Valid {
+		day := int(airDay.Int64)
+		r.AirDay = &day
+	}
+	if missedAlertAt.Valid {
+		r.MissedAlertAt = &missedAlertAt.Time
+	}
+	r.Groups = decodeList(groups)
+	r.BlockedGroups = decodeList(blockedGroups)
+	r.Codecs = decodeList(codecs)
+	r.Sources = decodeList(sources)
+	r.Tags = decodeList(tags)
+	r.Indexers = decodeIDs(indexers)
+	return r, nil
+}
+
 // ListRules returns all RSS rules.
 func ListRules() ([]models.RSSRule, error) {
-	rows, err := database.DB.Query(`
-		SELECT r.id, r.name, r.query, r.show_name, r.season, r.media_type,
-		       r.min_seeders, r.resolution, r.enabled, r.last_check, r.created_at,
-		       (SELECT COUNT(*) FROM rss_matches WHERE rule_id = r.id) as match_count
+	rows, err := database.DB.Query(`SELECT ` + ruleColumns + `
 		FROM rss_rules r
 		ORDER BY r.created_at DESC
 	`)
@@ -186,15 +402,33 @@ func ListRules() ([]models.RSSRule, error) {
 
 	var rules []models.RSSRule
 	for rows.Next() {
-		var r models.RSSRule
-		var lastCheck sql.NullTime
-		if err := rows.Scan(&r.ID, &r.Name, &r.Query, &r.ShowName, &r.Season,
-			&r.MediaType, &r.MinSeeders, &r.Resolution, &r.Enabled,
-			&lastCheck, &r.CreatedAt, &r.MatchCount); err != nil {
+		r, err := scanRule(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan rule: %w", err)
 		}
-		if lastCheck.Valid {
-			r.LastCheck = &lastCheck.Time
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+// ListCompletedRules returns rules that completed automatically, newest first.
+func ListCompletedRules() ([]models.RSSRule, error) {
+	rows, err := database.DB.Query(`SELECT ` + ruleColumns + `
+		FROM rss_rules r
+		WHERE r.completed_at IS NOT NULL
+		ORDER BY r.completed_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list completed rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []models.RSSRule
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan rule: %w", err)
 		}
 		rules = append(rules, r)
 	}
@@ -204,34 +438,35 @@ func ListRules() ([]models.RSSRule, error) {
 
 // GetRule returns a single RSS rule by ID.
 func GetRule(id int64) (*models.RSSRule, error) {
-	var r models.RSSRule
-	var lastCheck sql.NullTime
-	err := database.DB.QueryRow(`
-		SELECT r.id, r.name, r.query, r.show_name, r.season, r.media_type,
-		       r.min_seeders, r.resolution, r.enabled, r.last_check, r.created_at,
-		       (SELECT COUNT(*) FROM rss_matches WHERE rule_id = r.id) as match_count
+	r, err := scanRule(database.DB.QueryRow(`SELECT `+ruleColumns+`
 		FROM rss_rules r WHERE r.id = ?
-	`, id).Scan(&r.ID, &r.Name, &r.Query, &r.ShowName, &r.Season,
-		&r.MediaType, &r.MinSeeders, &r.Resolution, &r.Enabled,
-		&lastCheck, &r.CreatedAt, &r.MatchCount)
+	`, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("get rule: %w", err)
 	}
-	if lastCheck.Valid {
-		r.LastCheck = &lastCheck.Time
-	}
 	return &r, nil
 }
 
 // CreateRule inserts a new RSS rule.
 func CreateRule(r *models.RSSRule) error {
 	result, err := database.DB.Exec(`
-		INSERT INTO rss_rules (name, query, show_name, season, media_type, min_seeders, resolution, enabled)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, r.Name, r.Query, r.ShowName, r.Season, r.MediaType, r.MinSeeders, r.Resolution, r.Enabled)
+		INSERT INTO rss_rules (name, query, show_name, season, media_type, min_seeders, resolution,
+		       source_type, nyaa_category, nyaa_filter, feed_user, feed_url, indexers,
+		       include_pattern, exclude_pattern, groups, blocked_groups, min_size, max_size, codecs, sources,
+		       upgrade_policy, auto_link, profile_id, expected_episodes, end_date,
+		       poll_interval_minutes, category, tags, save_path, add_paused, sequential, first_last_piece,
+		       air_day, air_time, enabled)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.Name, r.Query, r.ShowName, r.Season, r.MediaType, r.MinSeeders, r.Resolution,
+		r.SourceType, r.NyaaCategory, r.NyaaFilter, r.FeedUser, r.FeedURL, encodeIDs(r.Indexers),
+		r.IncludePattern, r.ExcludePattern, encodeList(r.Groups), encodeList(r.BlockedGroups),
+		r.MinSize, r.MaxSize, encodeList(r.Codecs), encodeList(r.Sources),
+		r.UpgradePolicy, r.AutoLink, r.ProfileID, r.ExpectedEpisodes, r.EndDate,
+		r.PollIntervalMinutes, r.Category, encodeList(r.Tags), r.SavePath, r.AddPaused, r.Sequential, r.FirstLastPiece,
+		r.AirDay, r.AirTime, r.Enabled)
 	if err != nil {
 		return fmt.Errorf("create rule: %w", err)
 	}
@@ -239,13 +474,30 @@ func CreateRule(r *models.RSSRule) error {
 	return nil
 }
 
-// UpdateRule updates an existing RSS rule.
+// UpdateRule updates an existing RSS rule. Saving it enabled takes a
+// completed rule out of the completed archive, as ToggleRule does.
 func UpdateRule(r *models.RSSRule) error {
 	_, err := database.DB.Exec(`
 		UPDATE rss_rules SET name = ?, query = ?, show_name = ?, season = ?,
-		       media_type = ?, min_seeders = ?, resolution = ?, enabled = ?
+		       media_type = ?, min_seeders = ?, resolution = ?,
+		       source_type = ?, nyaa_category = ?, nyaa_filter = ?, feed_user = ?, feed_url = ?, indexers = ?,
+		       include_pattern = ?, exclude_pattern = ?, groups = ?, blocked_groups = ?,
+		       min_size = ?, max_size = ?, codecs = ?, sources = ?,
+		       upgrade_policy = ?, auto_link = ?, profile_id = ?,
+		       expected_episodes = ?, end_date = ?, poll_interval_minutes = ?,
+		       category = ?, tags = ?, save_path = ?, add_paused = ?, sequential = ?, first_last_piece = ?,
+		       air_day = ?, air_time = ?, enabled = ?,
+		       completed_at = CASE WHEN ? THEN NULL ELSE completed_at END,
+		       completed_reason = CASE WHEN ? THEN '' ELSE completed_reason END,
+		       next_check = NULL
 		WHERE id = ?
-	`, r.Name, r.Query, r.ShowName, r.Season, r.MediaType, r.MinSeeders, r.Resolution, r.Enabled, r.ID)
+	`, r.Name, r.Query, r.ShowName, r.Season, r.MediaType, r.MinSeeders, r.Resolution,
+		r.SourceType, r.NyaaCategory, r.NyaaFilter, r.FeedUser, r.FeedURL, encodeIDs(r.Indexers),
+		r.IncludePattern, r.ExcludePattern, encodeList(r.Groups), encodeList(r.BlockedGroups),
+		r.MinSize, r.MaxSize, encodeList(r.Codecs), encodeList(r.Sources),
+		r.UpgradePolicy, r.AutoLink, r.ProfileID, r.ExpectedEpisodes, r.EndDate,
+		r.PollIntervalMinutes, r.Category, encodeList(r.Tags), r.SavePath, r.AddPaused, r.Sequential, r.FirstLastPiece,
+		r.AirDay, r.AirTime, r.Enabled, r.Enabled, r.Enabled, r.ID)
 	if err != nil {
 		return fmt.Errorf("update rule: %w", err)
 	}
@@ -261,19 +513,56 @@ func DeleteRule(id int64) error {
 	return nil
 }
 
-// ToggleRule enables or disables an RSS rule.
+// ToggleRule enables or disables an RSS rule. Enabling a completed rule
+// takes it out of the completed archive.
 func ToggleRule(id int64, enabled bool) error {
-	_, err := database.DB.Exec("UPDATE rss_rules SET enabled = ? WHERE id = ?", enabled, id)
+	query := "UPDATE rss_rules SET enabled = ? WHERE id = ?"
+	if enabled {
+		query = "UPDATE rss_rules SET enabled = ?, completed_at = NULL, completed_reason = '' WHERE id = ?"
+	}
+	_, err := database.DB.Exec(query, enabled, id)
 	if err != nil {
 		return fmt.Errorf("toggle rule: %w", err)
 	}
 	return nil
 }
 
+// matchColumns is the column list read by scanMatch, in order.
+const matchColumns = `
+	m.id, m.rule_id, m.title, m.hash, m.info_hash, m.matched, m.status, r.name,
+	m.season, m.episode, m.resolution, m.release_group, m.revision, m.score, m.upgrade_of,
+	m.reason, m.magnet, m.attempts, m.next_retry`
+
+func scanMatch(row rowScanner) (models.RSSMatch, error) {
+	var m models.RSSMatch
+	var season, episode, upgradeOf sql.NullInt64
+	var nextRetry sql.NullTime
+	err := row.Scan(&m.ID, &m.RuleID, &m.Title, &m.Hash, &m.InfoHash, &m.Matched, &m.Status, &m.RuleName,
+		&season, &episode, &m.Resolution, &m.Group, &m.Revision, &m.Score, &upgradeOf,
+		&m.Reason, &m.Magnet, &m.Attempts, &nextRetry)
+	if err != nil {
+		return m, err
+	}
+	if season.Valid {
+		n := int(season.Int64)
+		m.Season = &n
+	}
+	if episode.Valid {
+		n := int(episode.Int64)
+		m.Episode = &n
+	}
+	if upgradeOf.Valid {
+		m.UpgradeOf = &upgradeOf.Int64
+	}
+	if nextRetry.Valid {
+		m.NextRetry = &nextRetry.Time
+	}
+	return m, nil
+}
+
 // ListMatches returns matches, optionally filtered by rule ID.
 func ListMatches(ruleID int64, limit int) ([]models.RSSMatch, error) {
-	query := `
-		SELECT m.id, m.rule_id, m.title, m.hash, m.matched, m.status, r.name
+	query := `SELECT ` + matchColumns + `
 		FROM rss_matches m
 		JOIN rss_rules r ON r.id = m.rule_id
 	`
@@ -291,6 +580,10 @@ func ListMatches(ruleID int64, limit int) ([]models.RSSMatch, error) {
 		args = append(args, limit)
 	}
 
+	return queryMatches(query, args...)
+}
+
+func queryMatches(query string, args ...interface{}) ([]models.RSSMatch, error) {
 	rows, err := database.DB.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("list matches: %w", err)
@@ -299,8 +592,8 @@ func ListMatches(ruleID int64, limit int) ([]models.RSSMatch, error) {
 
 	var matches []models.RSSMatch
 	for rows.Next() {
-		var m models.RSSMatch
-		if err := rows.Scan(&m.ID, &m.RuleID, &m.Title, &m.Hash, &m.Matched, &m.Status, &m.RuleName); err != nil {
+		m, err := scanMatch(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan match: %w", err)
 		}
 		matches = append(matches, m)
@@ -309,33 +602,376 @@ func ListMatches(ruleID int64, limit int) ([]models.RSSMatch, error) {
 	return matches, nil
 }
 
-// InsertMatch records a new RSS match.
-func InsertMatch(ruleID int64, title, hash, status string) error {
+// InsertMatch records a new RSS match; m.Hash is the per-rule dedupe key.
+func InsertMatch(m *models.RSSMatch) error {
+	result, err := database.DB.Exec(`
+		INSERT OR IGNORE INTO rss_matches (rule_id, title, hash, info_hash, status,
+		       season, episode, resolution, release_group, revision, score, upgrade_of, reason, magnet)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, m.RuleID, m.Title, m.Hash, m.InfoHash, m.Status,
+		m.Season, m.Episode, m.Resolution, m.Group, m.Revision, m.Score, m.UpgradeOf, m.Reason, m.Magnet)
+	if err != nil {
+		return err
+	}
+	m.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetMatch returns a single match by ID, or nil if it doesn't exist.
+func GetMatch(id int64) (*models.RSSMatch, error) {
+	m, err := scanMatch(database.DB.QueryRow(`SELECT `+matchColumns+`
+		FROM rss_matches m
+		JOIN rss_rules r ON r.id = m.rule_id
+		WHERE m.id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get match: %w", err)
+	}
+	return &m, nil
+}
+
+// SetMatchStatus updates the status of a match.
+func SetMatchStatus(id int64, status string) error {
+	_, err := database.DB.Exec("UPDATE rss_matches SET status = ? WHERE id = ?", status, id)
+	return err
+}
+
+// updateMatchDecision records the outcome for an existing match row.
+func updateMatchDecision(m *models.RSSMatch) error {
+	_, err := database.DB.Exec(`
+		UPDATE rss_matches SET status = ?, reason = ?, upgrade_of = ? WHERE id = ?
+	`, m.Status, m.Reason, m.UpgradeOf, m.ID)
+	return err
+}
+
+// retryableMatches returns pending and failed grabs with a magnet that are
+// due for another attempt, oldest first.
+func retryableMatches(maxAttempts int) ([]models.RSSMatch, error) {
+	return queryMatches(`SELECT `+matchColumns+`
+		FROM rss_matches m
+		JOIN rss_rules r ON r.id = m.rule_id
+		WHERE m.status IN ('pending', 'failed') AND m.magnet != '' AND m.attempts < ?
+		  AND (m.next_retry IS NULL OR m.next_retry <= datetime('now'))
+		ORDER BY m.matched
+	`, maxAttempts)
+}
+
+// recordRetry stores the outcome of a retry. A positive delay schedules the
+// next attempt; otherwise none is scheduled.
+func recordRetry(m *models.RSSMatch, delay time.Duration) error {
+	var next interface{}
+	if delay > 0 {
+		next = fmt.Sprintf("+%d seconds", int(delay.Seconds()))
+	}
 	_, err := database.DB.Exec(`
-		INSERT OR IGNORE INTO rss_matches (rule_id, title, hash, status) VALUES (?, ?, ?, ?)
-	`, ruleID, title, hash, status)
+		UPDATE rss_matches SET status = ?, reason = ?, magnet = ?, info_hash = ?, attempts = ?,
+		       next_retry = CASE WHEN ? IS NULL THEN NULL ELSE datetime('now', ?) END
+		WHERE id = ?
+	`, m.Status, m.Reason, m.Magnet, m.InfoHash, m.Attempts, next, next, m.ID)
 	return err
 }
 
+// dueCandidates returns the delayed candidates of a rule for episodes whose
+// first candidate was seen at least delay ago, best first within each episode.
+func dueCandidates(ruleID int64, delay time.Duration) ([]models.RSSMatch, error) {
+	return queryMatches(`SELECT `+matchColumns+`
+		FROM rss_matches m
+		JOIN rss_rules r ON r.id = m.rule_id
+		JOIN (
+			SELECT season, episode FROM rss_matches
+			WHERE rule_id = ? AND status = 'candidate'
+			GROUP BY season, episode
+			HAVING MIN(matched) <= datetime('now', ?)
+		) due ON due.season = m.season AND due.episode = m.episode
+		WHERE m.rule_id = ? AND m.status = 'candidate'
+		ORDER BY m.season, m.episode, m.score DESC, m.revision DESC, m.matched
+	`, ruleID, fmt.Sprintf("-%d seconds", int(delay.Seconds())), ruleID)
+}
+
+// bestEpisodeMatch returns the best release a rule has taken for an episode,
+// ignoring undecided, skipped, rejected, failed and replaced rows, or nil if
+// there is none.
+func bestEpisodeMatch(ruleID int64, season, episode int) (*models.RSSMatch, error) {
+	m, err := scanMatch(database.DB.QueryRow(`SELECT `+matchColumns+`
+		FROM rss_matches m
+		JOIN rss_rules r ON r.id = m.rule_id
+		WHERE m.rule_id = ? AND m.season = ? AND m.episode = ?
+		  AND m.status NOT IN ('candidate', 'skipped', 'rejected', 'failed', 'replaced')
+		ORDER BY m.score DESC, m.revision DESC, m.matched DESC
+		LIMIT 1
+	`, ruleID, season, episode))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("best episode match: %w", err)
+	}
+	return &m, nil
+}
+
+// matchesByInfoHash returns matches recorded for a torrent.
+func matchesByInfoHash(infoHash string) ([]models.RSSMatch, error) {
+	return queryMatches(`SELECT `+matchColumns+`
+		FROM rss_matches m
+		JOIN rss_rules r ON r.id = m.rule_id
+		WHERE m.info_hash = ?
+	`, infoHash)
+}
+
+// RuleCategories returns the qBittorrent categories enabled rules set for
+// their grabs, other than the global default.
+func RuleCategories() ([]string, error) {
+	rows, err := database.DB.Query(
+		"SELECT DISTINCT category FROM rss_rules WHERE enabled = 1 AND category != ''")
+	if err != nil {
+		return nil, fmt.Errorf("rule categories: %w", err)
+	}
+	defer rows.Close()
+
+	var cats []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+		cats = append(cats, c)
+	}
+	return cats, rows.Err()
+}
+
 // ClearMatches deletes all matches for a rule.
 func ClearMatches(ruleID int64) error {
-	_, err := database.DB.Exec("DELETE FROM rss_matches WHERE rule_id = ?", ruleID)
+	_, err := database.DB.Exec(
+		"DELETE FROM rss_match_files WHERE match_id IN (SELECT id FROM rss_matches WHERE rule_id = ?)", ruleID)
+	if err != nil {
+		return err
+	}
+	_, err = database.DB.Exec("DELETE FROM rss_matches WHERE rule_id = ?", ruleID)
 	return err
 }
 
+// recordLinkedFiles remembers the library files a match linked, with their
+// device and inode so later removal can tell if the path was reused.
+func recordLinkedFiles(matchID int64, paths []string) error {
+	for _, path := range paths {
+		id, ok := statFileID(path)
+		if !ok {
+			continue
+		}
+		_, err := database.DB.Exec(
+			"INSERT INTO rss_match_files (match_id, path, dev, ino) VALUES (?, ?, ?, ?)",
+			matchID, path, int64(id.Dev), int64(id.Ino),
+		)
+		if err != nil {
+			return fmt.Errorf("record linked file: %w", err)
+		}
+	}
+	return nil
+}
+
+// matchLinkedFiles returns the library files recorded for a match.
+func matchLinkedFiles(matchID int64) ([]linkedFile, error) {
+	rows, err := database.DB.Query("SELECT path, dev, ino FROM rss_match_files WHERE match_id = ?", matchID)
+	if err != nil {
+		return nil, fmt.Errorf("query linked files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []linkedFile
+	for rows.Next() {
+		var f linkedFile
+		var dev, ino int64
+		if err := rows.Scan(&f.Path, &dev, &ino); err != nil {
+			return nil, err
+		}
+		f.ID = fileID{Dev: uint64(dev), Ino: uint64(ino)}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
 // --- Helpers ---
 
-func isAlreadyMatched(ruleID int64, hash string) bool {
+// isAlreadyMatched checks the dedupe key, plus the title hash against legacy
+// rows recorded before info hashes were stored.
+func isAlreadyMatched(ruleID int64, hash, titleHash string) bool {
 	var count int
-	database.DB.QueryRow("SELECT COUNT(*) FROM rss_matches WHERE rule_id = ? AND hash = ?", ruleID, hash).Scan(&count)
+	database.DB.QueryRow(`
+		SELECT COUNT(*) FROM rss_matches
+		WHERE rule_id = ? AND (hash = ? OR (info_hash = '' AND hash = ?))
+	`, ruleID, hash, titleHash).Scan(&count)
 	return count > 0
 }
 
-func updateLastCheck(ruleID int64) {
-	database.DB.Exec("UPDATE rss_rules SET last_check = CURRENT_TIMESTAMP WHERE id = ?", ruleID)
+// completeRule disables a rule and moves it to the completed archive.
+func completeRule(ruleID int64, reason string) error {
+	_, err := database.DB.Exec(`
+		UPDATE rss_rules SET enabled = 0, completed_at = CURRENT_TIMESTAMP, completed_reason = ?
+		WHERE id = ?
+	`, reason, ruleID)
+	if err != nil {
+		return fmt.Errorf("complete rule: %w", err)
+	}
+	return nil
+}
+
+// lastGrab returns when the rule last grabbed a release, or nil if never.
+func lastGrab(ruleID int64) (*time.Time, error) {
+	var last time.Time
+	err := database.DB.QueryRow(`
+		SELECT matched FROM rss_matches
+		WHERE rule_id = ? AND status IN ('downloaded', 'pending', 'linked')
+		ORDER BY matched DESC LIMIT 1
+	`, ruleID).Scan(&last)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("last grab: %w", err)
+	}
+	return &last, nil
+}
+
+// recordCheck stores a successful check and when the rule is next due.
+func recordCheck(ruleID int64, next time.Time) {
+	database.DB.Exec(`
+		UPDATE rss_rules SET last_check = CURRENT_TIMESTAMP, last_success = CURRENT_TIMESTAMP,
+		       next_check = ?, consecutive_failures = 0, last_error = ''
+		WHERE id = ?
+	`, next.UTC(), ruleID)
+}
+
+// recordFailure stores a failed check, its error and the backed-off next check.
+func recordFailure(ruleID int64, failures int, lastError string, next time.Time) {
+	database.DB.Exec(`
+		UPDATE rss_rules SET last_check = CURRENT_TIMESTAMP, next_check = ?,
+		       consecutive_failures = ?, last_error = ?
+		WHERE id = ?
+	`, next.UTC(), failures, lastError, ruleID)
+}
+
+// episodeTimes returns when each of a rule's grabbed episodes was first
+// matched, oldest first. Backfilled library rows are left out.
+func episodeTimes(ruleID int64) ([]episodeTime, error) {
+	rows, err := database.DB.Query(`
+		SELECT season, episode, matched FROM rss_matches
+		WHERE rule_id = ? AND episode IS NOT NULL
+		  AND status IN ('downloaded', 'pending', 'linked', 'failed', 'candidate', 'replaced')
+		ORDER BY matched
+	`, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("episode times: %w", err)
+	}
+	defer rows.Close()
+
+	seen := map[[2]int]bool{}
+	var times []episodeTime
+	for rows.Next() {
+		var et episodeTime
+		if err := rows.Scan(&et.season, &et.episode, &et.matched); err != nil {
+			return nil, fmt.Errorf("scan episode time: %w", err)
+		}
+		key := [2]int{et.season, et.episode}
+		if !seen[key] {
+			seen[key] = true
+			times = append(times, et)
+		}
+	}
+	return times, rows.Err()
+}
+
+// recentGrabs returns releases grabbed in the last days days, newest first.
+func recentGrabs(days int) ([]grabEvent, error) {
+	rows, err := database.DB.Query(`
+		SELECT m.id, m.title, m.status, m.matched, m.season, m.episode, r.show_name, r.name
+		FROM rss_matches m
+		JOIN rss_rules r ON r.id = m.rule_id
+		WHERE m.status IN ('downloaded', 'pending', 'linked', 'failed', 'replaced')
+		  AND m.matched >= datetime('now', ?)
+		ORDER BY m.matched DESC
+	`, fmt.Sprintf("-%d days", days))
+	if err != nil {
+		return nil, fmt.Errorf("recent grabs: %w", err)
+	}
+	defer rows.Close()
+
+	var grabs []grabEvent
+	for rows.Next() {
+		var g grabEvent
+		var season, episode sql.NullInt64
+		if err := rows.Scan(&g.id, &g.title, &g.status, &g.matched, &season, &episode, &g.showName, &g.ruleName); err != nil {
+			return nil, fmt.Errorf("scan grab: %w", err)
+		}
+		if season.Valid {
+			n := int(season.Int64)
+			g.season = &n
+		}
+		if episode.Valid {
+			n := int(episode.Int64)
+			g.episode = &n
+		}
+		grabs = append(grabs, g)
+	}
+	return grabs, rows.Err()
+}
+
+// setMissedAlert records when a missed episode was reported for a rule.
+func setMissedAlert(ruleID int64) {
+	database.DB.Exec("UPDATE rss_rules SET missed_alert_at = CURRENT_TIMESTAMP WHERE id = ?", ruleID)
+}
+
+// recordLastMatch stamps the rule's last grab time.
+func recordLastMatch(ruleID int64) {
+	database.DB.Exec("UPDATE rss_rules SET last_match = CURRENT_TIMESTAMP WHERE id = ?", ruleID)
+}
+
+// matchKey returns the dedupe key for a result: its info hash when known.
+func matchKey(result models.NyaaResult) string {
+	if result.InfoHash != "" {
+		return result.InfoHash
+	}
+	return hashTitle(result.Title)
 }
 
 func hashTitle(title string) string {
 	h := sha256.Sum256([]byte(title))
 	return hex.EncodeToString(h[:16]) // 32-char hex string
 }
+
+// encodeList stores a string list as a JSON array ("" when empty).
+func encodeList(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(list)
+	return string(data)
+}
+
+func decodeList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var list []string
+	json.Unmarshal([]byte(s), &list)
+	return list
+}
+
+func encodeIDs(ids []int64) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(ids)
+	return string(data)
+}
+
+func decodeIDs(s string) []int64 {
+	if s == "" {
+		return nil
+	}
+	var ids []int64
+	json.Unmarshal([]byte(s), &ids)
+	return ids
+}
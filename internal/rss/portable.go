@@ -0,0 +1,451 @@
+package rss
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"link-anime/internal/indexer"
+	"link-anime/internal/models"
+	"link-anime/internal/parser"
+	"link-anime/internal/qbit"
+	"link-anime/internal/quality"
+)
+
+// exportVersion is bumped when the export format changes incompatibly.
+const exportVersion = 1
+
+// Conflict modes for imported rules whose name already exists.
+const (
+	ConflictSkip      = "skip"      // keep the existing rule
+	ConflictOverwrite = "overwrite" // replace the existing rule's settings
+	ConflictRename    = "rename"    // import under a new name
+)
+
+// RuleSet is the portable export format: rules without IDs, match state or
+// timestamps, with quality profiles referenced by name. It is written as JSON
+// or YAML with the same keys.
+type RuleSet struct {
+	Version    int            `json:"version" yaml:"version"`
+	ExportedAt time.Time      `json:"exportedAt" yaml:"exportedAt"`
+	Rules      []PortableRule `json:"rules" yaml:"rules"`
+}
+
+// PortableRule is an RSS rule as exported.
+type PortableRule struct {
+	Name                string   `json:"name" yaml:"name"`
+	Query               string   `json:"query,omitempty" yaml:"query,omitempty"`
+	ShowName            string   `json:"showName" yaml:"showName"`
+	Season              int      `json:"season,omitempty" yaml:"season,omitempty"`
+	MediaType           string   `json:"mediaType,omitempty" yaml:"mediaType,omitempty"`
+	Enabled             bool     `json:"enabled" yaml:"enabled"`
+	SourceType          string   `json:"sourceType,omitempty" yaml:"sourceType,omitempty"`
+	NyaaCategory        string   `json:"nyaaCategory,omitempty" yaml:"nyaaCategory,omitempty"`
+	NyaaFilter          string   `json:"nyaaFilter,omitempty" yaml:"nyaaFilter,omitempty"`
+	FeedUser            string   `json:"feedUser,omitempty" yaml:"feedUser,omitempty"`
+	FeedURL             string   `json:"feedUrl,omitempty" yaml:"feedUrl,omitempty"`
+	Indexers            []string `json:"indexers,omitempty" yaml:"indexers,omitempty"` // indexer names
+	MinSeeders          int      `json:"minSeeders,omitempty" yaml:"minSeeders,omitempty"`
+	Resolution          string   `json:"resolution,omitempty" yaml:"resolution,omitempty"`
+	IncludePattern      string   `json:"includePattern,omitempty" yaml:"includePattern,omitempty"`
+	ExcludePattern      string   `json:"excludePattern,omitempty" yaml:"excludePattern,omitempty"`
+	Groups              []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+	BlockedGroups       []string `json:"blockedGroups,omitempty" yaml:"blockedGroups,omitempty"`
+	MinSize             int64    `json:"minSize,omitempty" yaml:"minSize,omitempty"`
+	MaxSize             int64    `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`
+	Codecs              []string `json:"codecs,omitempty" yaml:"codecs,omitempty"`
+	Sources             []string `json:"sources,omitempty" yaml:"sources,omitempty"`
+	UpgradePolicy       string   `json:"upgradePolicy,omitempty" yaml:"upgradePolicy,omitempty"`
+	AutoLink            bool     `json:"autoLink,omitempty" yaml:"autoLink,omitempty"`
+	Category            string   `json:"category,omitempty" yaml:"category,omitempty"`
+	Tags                []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	SavePath            string   `json:"savePath,omitempty" yaml:"savePath,omitempty"`
+	AddPaused           bool     `json:"addPaused,omitempty" yaml:"addPaused,omitempty"`
+	Sequential          bool     `json:"sequential,omitempty" yaml:"sequential,omitempty"`
+	FirstLastPiece      bool     `json:"firstLastPiece,omitempty" yaml:"firstLastPiece,omitempty"`
+	Profile             string   `json:"profile,omitempty" yaml:"profile,omitempty"` // quality profile name
+	ExpectedEpisodes    int      `json:"expectedEpisodes,omitempty" yaml:"expectedEpisodes,omitempty"`
+	EndDate             string   `json:"endDate,omitempty" yaml:"endDate,omitempty"` // YYYY-MM-DD
+	PollIntervalMinutes int      `json:"pollIntervalMinutes,omitempty" yaml:"pollIntervalMinutes,omitempty"`
+	AirDay              *int     `json:"airDay,omitempty" yaml:"airDay,omitempty"`
+	AirTime             string   `json:"airTime,omitempty" yaml:"airTime,omitempty"`
+}
+
+// ImportResult reports what an import did, by rule name.
+type ImportResult struct {
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Skipped []string `json:"skipped"`
+	Errors  []string `json:"errors"`
+}
+
+// ValidConflict reports whether mode is a known conflict mode.
+func ValidConflict(mode string) bool {
+	switch mode {
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+		return true
+	}
+	return false
+}
+
+// ExportRules returns all rules in the portable format.
+func ExportRules() (*RuleSet, error) {
+	rules, err := ListRules()
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := quality.List()
+	if err != nil {
+		return nil, err
+	}
+	profileNames := make(map[int64]string, len(profiles))
+	for _, p := range profiles {
+		profileNames[p.ID] = p.Name
+	}
+	indexers, err := indexer.List()
+	if err != nil {
+		return nil, err
+	}
+	indexerNames := make(map[int64]string, len(indexers))
+	for _, ix := range indexers {
+		indexerNames[ix.ID] = ix.Name
+	}
+
+	set := &RuleSet{Version: exportVersion, ExportedAt: time.Now().UTC(), Rules: []PortableRule{}}
+	for _, r := range rules {
+		pr := PortableRule{
+			Name: r.Name, Query: r.Query, ShowName: r.ShowName, Season: r.Season, MediaType: r.MediaType,
+			Enabled:    r.Enabled && r.CompletedAt == nil,
+			SourceType: r.SourceType, NyaaCategory: r.NyaaCategory, NyaaFilter: r.NyaaFilter,
+			FeedUser: r.FeedUser, FeedURL: r.FeedURL,
+			MinSeeders: r.MinSeeders, Resolution: r.Resolution,
+			IncludePattern: r.IncludePattern, ExcludePattern: r.ExcludePattern,
+			Groups: r.Groups, BlockedGroups: r.BlockedGroups,
+			MinSize: r.MinSize, MaxSize: r.MaxSize, Codecs: r.Codecs, Sources: r.Sources,
+			UpgradePolicy: r.UpgradePolicy, AutoLink: r.AutoLink,
+			Category: r.Category, Tags: r.Tags, SavePath: r.SavePath,
+			AddPaused: r.AddPaused, Sequential: r.Sequential, FirstLastPiece: r.FirstLastPiece,
+			Profile:             profileNames[r.ProfileID],
+			ExpectedEpisodes:    r.ExpectedEpisodes,
+			PollIntervalMinutes: r.PollIntervalMinutes,
+			AirDay:              r.AirDay,
+			AirTime:             r.AirTime,
+		}
+		if r.EndDate != nil {
+			pr.EndDate = r.EndDate.Format("2006-01-02")
+		}
+		for _, id := range r.Indexers {
+			if name, ok := indexerNames[id]; ok {
+				pr.Indexers = append(pr.Indexers, name)
+			}
+		}
+		set.Rules = append(set.Rules, pr)
+	}
+	return set, nil
+}
+
+// toRule converts a portable rule, resolving its profile and indexers by name.
+func (pr PortableRule) toRule(profileIDs, indexerIDs map[string]int64) (models.RSSRule, error) {
+	r := models.RSSRule{
+		Name: pr.Name, Query: pr.Query, ShowName: pr.ShowName, Season: pr.Season, MediaType: pr.MediaType,
+		Enabled:    pr.Enabled,
+		SourceType: pr.SourceType, NyaaCategory: pr.NyaaCategory, NyaaFilter: pr.NyaaFilter,
+		FeedUser: pr.FeedUser, FeedURL: pr.FeedURL,
+		MinSeeders: pr.MinSeeders, Resolution: pr.Resolution,
+		IncludePattern: pr.IncludePattern, ExcludePattern: pr.ExcludePattern,
+		Groups: pr.Groups, BlockedGroups: pr.BlockedGroups,
+		MinSize: pr.MinSize, MaxSize: pr.MaxSize, Codecs: pr.Codecs, Sources: pr.Sources,
+		UpgradePolicy: pr.UpgradePolicy, AutoLink: pr.AutoLink,
+		Category: pr.Category, Tags: pr.Tags, SavePath: pr.SavePath,
+		AddPaused: pr.AddPaused, Sequential: pr.Sequential, FirstLastPiece: pr.FirstLastPiece,
+		ExpectedEpisodes:    pr.ExpectedEpisodes,
+		PollIntervalMinutes: pr.PollIntervalMinutes,
+		AirDay:              pr.AirDay,
+		AirTime:             pr.AirTime,
+	}
+
+	if r.Name == "" || r.ShowName == "" {
+		return r, fmt.Errorf("name and showName are required")
+	}
+	if r.MediaType == "" {
+		r.MediaType = "series"
+	}
+	if r.Season == 0 && r.MediaType == "series" {
+		r.Season = 1
+	}
+	if pr.Profile != "" {
+		id, ok := profileIDs[strings.ToLower(pr.Profile)]
+		if !ok {
+			return r, fmt.Errorf("quality profile %q not found", pr.Profile)
+		}
+		r.ProfileID = id
+	}
+	for _, name := range pr.Indexers {
+		id, ok := indexerIDs[strings.ToLower(name)]
+		if !ok {
+			return r, fmt.Errorf("indexer %q not found", name)
+		}
+		r.Indexers = append(r.Indexers, id)
+	}
+	if pr.EndDate != "" {
+		end, err := time.Parse("2006-01-02", pr.EndDate)
+		if err != nil {
+			return r, fmt.Errorf("endDate must be YYYY-MM-DD")
+		}
+		r.EndDate = &end
+	}
+
+	return r, ValidateRule(&r)
+}
+
+// ImportRules saves a rule set, resolving name clashes with existing rules
+// per conflict. Invalid rules are reported and skipped; the rest still import.
+func ImportRules(set *RuleSet, conflict string) (*ImportResult, error) {
+	if set.Version > exportVersion {
+		return nil, fmt.Errorf("rule set version %d is newer than supported version %d", set.Version, exportVersion)
+	}
+
+	existing, err := ListRules()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]models.RSSRule, len(existing))
+	for _, r := range existing {
+		byName[strings.ToLower(r.Name)] = r
+	}
+
+	profiles, err := quality.List()
+	if err != nil {
+		return nil, err
+	}
+	profileIDs := make(map[string]int64, len(profiles))
+	for _, p := range profiles {
+		profileIDs[strings.ToLower(p.Name)] = p.ID
+	}
+	indexers, err := indexer.List()
+	if err != nil {
+		return nil, err
+	}
+	indexerIDs := make(map[string]int64, len(indexers))
+	for _, ix := range indexers {
+		indexerIDs[strings.ToLower(ix.Name)] = ix.ID
+	}
+
+	res := &ImportResult{Created: []string{}, Updated: []string{}, Skipped: []string{}, Errors: []string{}}
+	for _, pr := range set.Rules {
+		rule, err := pr.toRule(profileIDs, indexerIDs)
+		if err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", pr.Name, err))
+			continue
+		}
+
+		if old, clash := byName[strings.ToLower(rule.Name)]; clash {
+			switch conflict {
+			case ConflictOverwrite:
+				rule.ID = old.ID
+				if err := UpdateRule(&rule); err != nil {
+					res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", rule.Name, err))
+					continue
+				}
+				res.Updated = append(res.Updated, rule.Name)
+				continue
+			case ConflictRename:
+				rule.Name = uniqueName(rule.Name, byName)
+			default:
+				res.Skipped = append(res.Skipped, rule.Name)
+				continue
+			}
+		}
+
+		if err := CreateRule(&rule); err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", rule.Name, err))
+			continue
+		}
+		byName[strings.ToLower(rule.Name)] = rule
+		res.Created = append(res.Created, rule.Name)
+	}
+	return res, nil
+}
+
+// uniqueName appends " (2)", " (3)", ... until the name is unused.
+func uniqueName(name string, taken map[string]models.RSSRule) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		if _, ok := taken[strings.ToLower(candidate)]; !ok {
+			return candidate
+		}
+	}
+}
+
+// --- qBittorrent auto-downloader import ---
+
+// FromQbitRules converts qBittorrent RSS auto-download rules into a rule set.
+// Each rule reads its first feed; rules without a feed are reported as errors.
+func FromQbitRules(qrules map[string]qbit.RSSRule) (*RuleSet, []string) {
+	names := make([]string, 0, len(qrules))
+	for name := range qrules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	set := &RuleSet{Version: exportVersion, ExportedAt: time.Now().UTC(), Rules: []PortableRule{}}
+	var errs []string
+	for _, name := range names {
+		pr, err := fromQbitRule(name, qrules[name])
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		set.Rules = append(set.Rules, pr)
+	}
+	return set, errs
+}
+
+func fromQbitRule(name string, q qbit.RSSRule) (PortableRule, error) {
+	if len(q.AffectedFeeds) == 0 {
+		return PortableRule{}, fmt.Errorf("rule has no feeds")
+	}
+
+	parsed := parser.ParseReleaseName(name)
+	pr := PortableRule{
+		Name:       name,
+		ShowName:   parsed.Name,
+		Enabled:    q.Enabled,
+		SourceType: SourceURL,
+		FeedURL:    q.AffectedFeeds[0],
+		Category:   q.AssignedCategory,
+		SavePath:   q.SavePath,
+		AddPaused:  q.AddPaused != nil && *q.AddPaused,
+	}
+	if pr.ShowName == "" {
+		pr.ShowName = name
+	}
+	if parsed.Season != nil {
+		pr.Season = *parsed.Season
+	}
+
+	// Nyaa feeds become native sources so filters and categories carry over
+	if u, err := url.Parse(pr.FeedURL); err == nil && strings.HasSuffix(u.Host, "nyaa.si") {
+		qs := u.Query()
+		pr.NyaaCategory = qs.Get("c")
+		pr.NyaaFilter = map[string]string{"0": "all", "1": "noremakes", "2": "trusted"}[qs.Get("f")]
+		pr.Query = qs.Get("q")
+		if user := qs.Get("u"); user != "" {
+			pr.SourceType, pr.FeedUser = SourceNyaaUser, user
+		} else if pr.Query != "" {
+			pr.SourceType = SourceNyaa
+		}
+		if pr.SourceType != SourceURL {
+			pr.FeedURL = ""
+		}
+	}
+
+	if q.UseRegex {
+		pr.IncludePattern = q.MustContain
+		pr.ExcludePattern = q.MustNotContain
+	} else {
+		var err error
+		if pr.IncludePattern, err = wildcardToRegex(q.MustContain); err != nil {
+			return PortableRule{}, err
+		}
+		if pr.ExcludePattern, err = wildcardToRegex(q.MustNotContain); err != nil {
+			return PortableRule{}, err
+		}
+	}
+
+	if season, last, ok := parseEpisodeFilter(q.EpisodeFilter); ok {
+		pr.Season = season
+		pr.ExpectedEpisodes = last
+	}
+	if q.SmartFilter {
+		pr.UpgradePolicy = UpgradeRevision
+	}
+	return pr, nil
+}
+
+// maxWildcardWords caps the words in one wildcard alternative. RE2 has no
+// lookahead, so matching words in any order means listing every order.
+const maxWildcardWords = 5
+
+// wildcardToRegex converts qBittorrent's wildcard syntax, where "|" separates
+// alternatives, spaces separate words that must all appear in any order, and
+// * and ? are wildcards.
+func wildcardToRegex(expr string) (string, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return "", nil
+	}
+
+	var alts []string
+	for _, alt := range strings.Split(expr, "|") {
+		var words []string
+		for _, w := range strings.Fields(alt) {
+			w = regexp.QuoteMeta(w)
+			w = strings.ReplaceAll(w, `\*`, ".*")
+			w = strings.ReplaceAll(w, `\?`, ".")
+			words = append(words, w)
+		}
+		if len(words) > maxWildcardWords {
+			return "", fmt.Errorf("wildcard %q has more than %d words; use a regex instead", strings.TrimSpace(alt), maxWildcardWords)
+		}
+		alts = append(alts, wordOrders(words)...)
+	}
+	if len(alts) == 1 {
+		return alts[0], nil
+	}
+	return "(?:" + strings.Join(alts, "|") + ")", nil
+}
+
+// wordOrders returns each distinct order of words joined by ".*".
+func wordOrders(words []string) []string {
+	if len(words) <= 1 {
+		return words
+	}
+	seen := make(map[string]bool)
+	var orders []string
+	for i, w := range words {
+		rest := append(append([]string{}, words[:i]...), words[i+1:]...)
+		for _, tail := range wordOrders(rest) {
+			if o := w + ".*" + tail; !seen[o] {
+				seen[o] = true
+				orders = append(orders, o)
+			}
+		}
+	}
+	return orders
+}
+
+var reEpisodeFilter = regexp.MustCompile(`^(\d+)x(.*)$`)
+
+// parseEpisodeFilter reads qBittorrent's "1x01-12;" style filter, returning
+// the season and, when the filter ends in a closed range or single episode,
+// the last episode.
+func parseEpisodeFilter(filter string) (season, last int, ok bool) {
+	m := reEpisodeFilter.FindStringSubmatch(strings.TrimSpace(filter))
+	if m == nil {
+		return 0, 0, false
+	}
+	season, _ = strconv.Atoi(m[1])
+
+	for _, part := range strings.Split(m[2], ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		end := part
+		if i := strings.Index(part, "-"); i >= 0 {
+			end = part[i+1:]
+		}
+		if n, err := strconv.Atoi(end); err == nil {
+			last = max(last, n)
+		} else {
+			last = 0 // open range like "13-": no known end
+			break
+		}
+	}
+	return season, last, true
+}
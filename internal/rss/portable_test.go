@@ -0,0 +1,135 @@
+package rss
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"link-anime/internal/qbit"
+)
+
+func TestWildcardToRegex(t *testing.T) {
+	tests := []struct {
+		expr  string
+		title string
+		match bool
+	}{
+		{"Frieren 1080p", "[SubsPlease] Frieren - 01 (1080p)", true},
+		{"1080p SubsPlease", "[SubsPlease] Frieren - 01 (1080p)", true},
+		{"1080p SubsPlease", "[Erai-raws] Frieren - 01 (1080p)", false},
+		{"Frieren*1080", "Frieren - 01 (1080p)", true},
+		{"S01E0? | Batch", "Show S01E05", true},
+		{"S01E0? | Batch", "Show (Batch)", true},
+		{"S01E0? | Batch", "Show S01E15", false},
+		{"[SubsPlease]", "[SubsPlease] Show", true},
+		{"[SubsPlease]", "SubsPlease Show", false},
+	}
+	for _, tt := range tests {
+		expr, err := wildcardToRegex(tt.expr)
+		if err != nil {
+			t.Fatalf("wildcardToRegex(%q): %v", tt.expr, err)
+		}
+		re := regexp.MustCompile("(?i)" + expr)
+		if got := re.MatchString(tt.title); got != tt.match {
+			t.Errorf("%q (regex %q) on %q = %v, want %v", tt.expr, expr, tt.title, got, tt.match)
+		}
+	}
+
+	if expr, _ := wildcardToRegex(""); expr != "" {
+		t.Errorf("empty wildcard = %q, want empty", expr)
+	}
+	if _, err := wildcardToRegex("a b c d e f"); err == nil {
+		t.Error("expected error for too many words")
+	}
+}
+
+func TestParseEpisodeFilter(t *testing.T) {
+	tests := []struct {
+		filter       string
+		season, last int
+		ok           bool
+	}{
+		{"1x01-12;", 1, 12, true},
+		{"2x1;3;5-7;", 2, 7, true},
+		{"1x13-;", 1, 0, true},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		season, last, ok := parseEpisodeFilter(tt.filter)
+		if season != tt.season || last != tt.last || ok != tt.ok {
+			t.Errorf("parseEpisodeFilter(%q) = %d, %d, %v; want %d, %d, %v",
+				tt.filter, season, last, ok, tt.season, tt.last, tt.ok)
+		}
+	}
+}
+
+func TestFromQbitRule(t *testing.T) {
+	pr, err := fromQbitRule("Frieren S2", qbit.RSSRule{
+		Enabled:       true,
+		MustContain:   "1080p",
+		EpisodeFilter: "2x01-10;",
+		AffectedFeeds: []string{"https://nyaa.si/?page=rss&u=subsplease&q=frieren&c=1_2&f=2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.SourceType != SourceNyaaUser || pr.FeedUser != "subsplease" || pr.Query != "frieren" || pr.FeedURL != "" {
+		t.Errorf("unexpected source: %+v", pr)
+	}
+	if pr.NyaaCategory != "1_2" || pr.NyaaFilter != "trusted" {
+		t.Errorf("unexpected nyaa options: %+v", pr)
+	}
+	if pr.ShowName != "Frieren" || pr.Season != 2 || pr.ExpectedEpisodes != 10 || pr.IncludePattern != "1080p" {
+		t.Errorf("unexpected rule: %+v", pr)
+	}
+
+	pr, err = fromQbitRule("Other", qbit.RSSRule{AffectedFeeds: []string{"https://feed.animetosho.org/rss2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.SourceType != SourceURL || pr.FeedURL != "https://feed.animetosho.org/rss2" {
+		t.Errorf("unexpected source: %+v", pr)
+	}
+
+	if _, err := fromQbitRule("NoFeeds", qbit.RSSRule{}); err == nil {
+		t.Error("expected error for rule without feeds")
+	}
+}
+
+func TestRuleSetYAMLRoundTrip(t *testing.T) {
+	day := 3
+	set := RuleSet{
+		Version:    exportVersion,
+		ExportedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Rules: []PortableRule{{
+			Name:           "Frieren",
+			ShowName:       "Sousou no Frieren",
+			Season:         2,
+			Enabled:        true,
+			IncludePattern: `(?:1080p|720p): "quoted" # not a comment`,
+			Groups:         []string{"SubsPlease", "Erai-raws"},
+			AirDay:         &day,
+			AirTime:        "18:00",
+		}},
+	}
+
+	data, err := yaml.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "showName: Sousou no Frieren") {
+		t.Errorf("YAML keys should match JSON keys:\n%s", data)
+	}
+
+	var got RuleSet
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, set) {
+		t.Errorf("round trip = %+v, want %+v", got, set)
+	}
+}
@@ -0,0 +1,134 @@
+package rss
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"link-anime/internal/feed"
+	"link-anime/internal/models"
+)
+
+const (
+	retryBase        = 5 * time.Minute // wait after the first failed retry, doubling after each
+	maxRetryAttempts = 10              // automatic retries before a match is left for manual retry
+)
+
+// retryMatches re-sends pending and failed grabs that are due, once
+// qBittorrent is reachable and there is disk space for them.
+func (p *Poller) retryMatches() {
+	client := p.getQbit()
+	if client == nil || !client.IsConfigured() || (p.hasSpace != nil && !p.hasSpace()) {
+		return
+	}
+
+	// Held from the read on, so a manual retry can't send a match in between
+	p.retryMu.Lock()
+	defer p.retryMu.Unlock()
+
+	due, err := retryableMatches(maxRetryAttempts)
+	if err != nil {
+		log.Printf("RSS retry: %v", err)
+		return
+	}
+	if len(due) == 0 {
+		return
+	}
+	if err := client.Ping(); err != nil {
+		log.Printf("RSS retry: qBittorrent unavailable, %d matches waiting: %v", len(due), err)
+		return
+	}
+
+	rules := map[int64]*models.RSSRule{}
+	for i := range due {
+		match := &due[i]
+		rule, ok := rules[match.RuleID]
+		if !ok {
+			if rule, err = GetRule(match.RuleID); err != nil {
+				log.Printf("RSS retry: %v", err)
+				continue
+			}
+			rules[match.RuleID] = rule
+		}
+		if rule == nil {
+			continue
+		}
+		p.retry(*rule, match)
+	}
+}
+
+// RetryMatch re-sends a pending or failed match right away, ignoring the
+// backoff. A non-empty magnet replaces the stored one, which also allows
+// re-sending a match that was downloaded but whose torrent is dead. It
+// returns nil if the match doesn't exist.
+func (p *Poller) RetryMatch(id int64, magnet string) (*models.RSSMatch, error) {
+	p.retryMu.Lock()
+	defer p.retryMu.Unlock()
+
+	match, err := GetMatch(id)
+	if err != nil || match == nil {
+		return nil, err
+	}
+
+	switch match.Status {
+	case "pending", "failed":
+	case "downloaded":
+		if magnet == "" {
+			return nil, fmt.Errorf("match was already sent; give a magnet to re-send it")
+		}
+	default:
+		return nil, fmt.Errorf("match is %s, not pending or failed", match.Status)
+	}
+
+	if magnet != "" {
+		if !strings.HasPrefix(magnet, "magnet:") && !strings.HasPrefix(magnet, "http") {
+			return nil, fmt.Errorf("magnet must be a magnet link or torrent URL")
+		}
+		match.Magnet = magnet
+		if hash := feed.MagnetInfoHash(magnet); hash != "" {
+			match.InfoHash = hash
+		}
+	}
+	if match.Magnet == "" {
+		return nil, fmt.Errorf("match has no stored magnet; give one to retry it")
+	}
+
+	rule, err := GetRule(match.RuleID)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, fmt.Errorf("rule %d not found", match.RuleID)
+	}
+
+	p.retry(*rule, match)
+	return match, nil
+}
+
+// retry sends a match again and records the outcome, scheduling the next
+// attempt with backoff if it fails.
+func (p *Poller) retry(rule models.RSSRule, match *models.RSSMatch) {
+	match.Attempts++
+	status, cause := p.send(rule, match.Magnet)
+	match.Status = status
+
+	var delay time.Duration
+	if cause == "" {
+		log.Printf("RSS retry [%s]: sent %s (attempt %d)", rule.Name, match.Title, match.Attempts)
+		match.Reason = fmt.Sprintf("sent on retry %d", match.Attempts)
+		match.NextRetry = nil
+		recordLastMatch(rule.ID)
+		p.broadcastMatch(rule, *match)
+	} else {
+		delay = backoffDelay(retryBase, match.Attempts-1)
+		log.Printf("RSS retry [%s]: %s: %s (attempt %d)", rule.Name, match.Title, cause, match.Attempts)
+		match.Reason = cause
+		next := time.Now().Add(delay)
+		match.NextRetry = &next
+	}
+
+	if err := recordRetry(match, delay); err != nil {
+		log.Printf("RSS retry [%s]: failed to record retry: %v", rule.Name, err)
+	}
+}
@@ -0,0 +1,175 @@
+package rss
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"link-anime/internal/database"
+	"link-anime/internal/models"
+	"link-anime/internal/qbit"
+	"link-anime/internal/ws"
+)
+
+// retryFixture opens a fresh database with one rule, and a poller with no
+// qBittorrent client so every send leaves the match pending.
+func retryFixture(t *testing.T) (*Poller, models.RSSRule) {
+	t.Helper()
+	if err := database.Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	rule := models.RSSRule{Name: "Show", Query: "Show", ShowName: "Show", Season: 1,
+		MediaType: "series", SourceType: SourceNyaa, Enabled: true}
+	if err := CreateRule(&rule); err != nil {
+		t.Fatal(err)
+	}
+	p := NewPoller(nil, func() *qbit.Client { return nil }, func() string { return "anime" }, nil, nil, nil)
+	return p, rule
+}
+
+func insertTestMatch(t *testing.T, ruleID int64, title, status, magnet string) int64 {
+	t.Helper()
+	m := models.RSSMatch{RuleID: ruleID, Title: title, Hash: hashTitle(title), Status: status, Magnet: magnet}
+	if err := InsertMatch(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.ID
+}
+
+func TestRetryMatchStatusGating(t *testing.T) {
+	p, rule := retryFixture(t)
+	const magnet = "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567"
+
+	tests := []struct {
+		name    string
+		status  string
+		stored  string
+		magnet  string
+		wantErr bool
+	}{
+		{"pending", "pending", magnet, "", false},
+		{"failed", "failed", magnet, "", false},
+		{"downloaded without new magnet", "downloaded", magnet, "", true},
+		{"downloaded with new magnet", "downloaded", magnet, magnet, false},
+		{"linked", "linked", magnet, magnet, true},
+		{"rejected", "rejected", magnet, "", true},
+		{"no stored magnet", "pending", "", "", true},
+		{"not a magnet", "pending", magnet, "ftp://example.com/x", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := insertTestMatch(t, rule.ID, tt.name, tt.status, tt.stored)
+			m, err := p.RetryMatch(id, tt.magnet)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if m.Status != "pending" || m.Attempts != 1 || m.NextRetry == nil {
+				t.Errorf("match = %+v; want pending, 1 attempt, next retry set", m)
+			}
+		})
+	}
+
+	if m, err := p.RetryMatch(9999, ""); m != nil || err != nil {
+		t.Errorf("missing match: got %v, %v; want nil, nil", m, err)
+	}
+}
+
+func TestRecordRetryScheduling(t *testing.T) {
+	_, rule := retryFixture(t)
+	id := insertTestMatch(t, rule.ID, "Show - 01", "failed", "magnet:?xt=urn:btih:abc")
+
+	due := func() bool {
+		t.Helper()
+		matches, err := retryableMatches(maxRetryAttempts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range matches {
+			if m.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+	if !due() {
+		t.Fatal("new failed match should be due")
+	}
+
+	m, err := GetMatch(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Attempts = 1
+	if err := recordRetry(m, backoffDelay(retryBase, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if due() {
+		t.Error("match should wait out its backoff")
+	}
+	if m, _ = GetMatch(id); m.NextRetry == nil || time.Until(*m.NextRetry) < retryBase-time.Minute {
+		t.Errorf("next retry = %v, want about %s from now", m.NextRetry, retryBase)
+	}
+
+	if err := recordRetry(m, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !due() {
+		t.Error("match with no delay should be due again")
+	}
+
+	m.Attempts = maxRetryAttempts
+	if err := recordRetry(m, 0); err != nil {
+		t.Fatal(err)
+	}
+	if due() {
+		t.Error("match past the attempt cap should be left for manual retry")
+	}
+}
+
+func TestRetryMatchesSkipsManuallySentMatch(t *testing.T) {
+	p, rule := retryFixture(t)
+	var adds int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/torrents/add" {
+			atomic.AddInt32(&adds, 1)
+		}
+		w.Write([]byte("Ok."))
+	}))
+	defer srv.Close()
+	client := qbit.New(srv.URL, "admin", "secret")
+	p.getQbit = func() *qbit.Client { return client }
+	p.hub = ws.NewHub()
+
+	id := insertTestMatch(t, rule.ID, "Show - 01", "failed", "magnet:?xt=urn:btih:abc")
+
+	// A manual retry holds the lock while the scheduled pass starts
+	p.retryMu.Lock()
+	done := make(chan struct{})
+	go func() {
+		p.retryMatches()
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	m, err := GetMatch(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Status, m.Attempts = "downloaded", 1
+	if err := recordRetry(m, 0); err != nil {
+		t.Fatal(err)
+	}
+	p.retryMu.Unlock()
+	<-done
+
+	if n := atomic.LoadInt32(&adds); n != 0 {
+		t.Errorf("scheduled retry re-sent a match already sent manually (%d adds)", n)
+	}
+	if m, _ = GetMatch(id); m.Status != "downloaded" || m.Attempts != 1 {
+		t.Errorf("match = %s after %d attempts, want downloaded after 1", m.Status, m.Attempts)
+	}
+}
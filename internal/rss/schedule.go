@@ -0,0 +1,130 @@
+package rss
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"link-anime/internal/database"
+	"link-anime/internal/models"
+)
+
+const (
+	defaultPollMinutes = 15
+	schedulerTick      = time.Minute      // how often the poller looks for due rules
+	maxBackoff         = 6 * time.Hour    // longest wait after repeated fetch failures
+	feedBurst          = 3                // feed requests allowed back-to-back
+	feedRefill         = 10 * time.Second // one more request allowed per refill
+)
+
+// PollInterval returns the global poll interval.
+func PollInterval() time.Duration {
+	return time.Duration(PollIntervalMinutes()) * time.Minute
+}
+
+// PollIntervalMinutes returns the global poll interval in minutes (default 15).
+func PollIntervalMinutes() int {
+	if v, err := database.GetSetting("rss_poll_interval_minutes"); err == nil && v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultPollMinutes
+}
+
+// ruleInterval returns how often a rule is polled.
+func ruleInterval(rule models.RSSRule) time.Duration {
+	if rule.PollIntervalMinutes > 0 {
+		return time.Duration(rule.PollIntervalMinutes) * time.Minute
+	}
+	return PollInterval()
+}
+
+// isDue reports whether a rule should be checked at now. Rules never checked
+// are due at once; older rows without next_check fall back to last_check.
+func isDue(rule models.RSSRule, now time.Time) bool {
+	switch {
+	case rule.NextCheck != nil:
+		return !now.Before(*rule.NextCheck)
+	case rule.LastCheck != nil:
+		return !now.Before(rule.LastCheck.Add(ruleInterval(rule)))
+	}
+	return true
+}
+
+// jittered adds up to 10% to d so rules sharing an interval drift apart
+// instead of firing together.
+func jittered(d time.Duration) time.Duration {
+	if spread := int64(d / 10); spread > 0 {
+		d += time.Duration(rand.Int63n(spread))
+	}
+	return d
+}
+
+// backoffDelay doubles the interval for each consecutive failure, capped at
+// maxBackoff but never shorter than the interval itself.
+func backoffDelay(interval time.Duration, failures int) time.Duration {
+	d := interval
+	for i := 0; i < failures && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	if d < interval {
+		d = interval
+	}
+	return d
+}
+
+// tokenBucket paces feed requests: up to capacity at once, then one per refill.
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	refill   time.Duration
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(capacity int, refill time.Duration) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(capacity),
+		refill:   refill,
+		tokens:   float64(capacity),
+		last:     time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += float64(now.Sub(b.last)) / float64(b.refill)
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * float64(b.refill))
+}
+
+// wait blocks until a request may be made. It returns false if stop closes first.
+func (b *tokenBucket) wait(stop <-chan struct{}) bool {
+	d := b.reserve(time.Now())
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
@@ -0,0 +1,44 @@
+package rss
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		failures int
+		want     time.Duration
+	}{
+		{15 * time.Minute, 0, 15 * time.Minute},
+		{15 * time.Minute, 1, 30 * time.Minute},
+		{15 * time.Minute, 3, 2 * time.Hour},
+		{15 * time.Minute, 10, maxBackoff},
+		{12 * time.Hour, 2, 12 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := backoffDelay(tt.interval, tt.failures); got != tt.want {
+			t.Errorf("backoffDelay(%s, %d) = %s, want %s", tt.interval, tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(2, 10*time.Second)
+	now := b.last
+
+	if d := b.reserve(now); d != 0 {
+		t.Fatalf("first request waited %s", d)
+	}
+	if d := b.reserve(now); d != 0 {
+		t.Fatalf("second request waited %s", d)
+	}
+	if d := b.reserve(now); d != 10*time.Second {
+		t.Fatalf("third request waited %s, want 10s", d)
+	}
+	// The third request's token is spent once it refills; after 30s two are back
+	if d := b.reserve(now.Add(30 * time.Second)); d != 0 {
+		t.Fatalf("request after refill waited %s", d)
+	}
+}
@@ -0,0 +1,88 @@
+package rss
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"link-anime/internal/feed"
+	"link-anime/internal/indexer"
+	"link-anime/internal/models"
+	"link-anime/internal/nyaa"
+)
+
+// Rule source types.
+const (
+	SourceNyaa     = "nyaa"      // Nyaa search for the rule's query
+	SourceNyaaUser = "nyaa_user" // an uploader's Nyaa feed, optionally narrowed by query
+	SourceURL      = "url"       // any RSS 2.0 or Atom feed
+	SourceIndexers = "indexers"  // the rule's query on its indexers, merged
+)
+
+// sourceKey identifies the feed a rule reads, so rules sharing a feed share a fetch.
+func sourceKey(rule models.RSSRule) string {
+	switch rule.SourceType {
+	case SourceURL:
+		return "url|" + rule.FeedURL
+	case SourceNyaaUser:
+		return strings.Join([]string{"nyaa_user", rule.FeedUser, rule.Query, rule.NyaaCategory, rule.NyaaFilter}, "|")
+	case SourceIndexers:
+		return fmt.Sprintf("indexers|%v|%s", rule.Indexers, rule.Query)
+	default:
+		return strings.Join([]string{"nyaa", rule.Query, rule.NyaaCategory, rule.NyaaFilter}, "|")
+	}
+}
+
+// fetchSource reads the feed a rule points at.
+func fetchSource(rule models.RSSRule) ([]models.NyaaResult, error) {
+	switch rule.SourceType {
+	case SourceURL:
+		return feed.Fetch(rule.FeedURL)
+	case SourceNyaaUser:
+		return nyaa.SearchFeed(rule.Query, rule.NyaaCategory, rule.NyaaFilter, rule.FeedUser)
+	case SourceIndexers:
+		return searchIndexers(rule)
+	default:
+		return nyaa.SearchFeed(rule.Query, rule.NyaaCategory, rule.NyaaFilter, "")
+	}
+}
+
+// searchIndexers runs the rule's query on its indexers. Each indexer keeps its
+// own category and filter. An indexer failing is logged; the rule only fails
+// when all of them do.
+func searchIndexers(rule models.RSSRule) ([]models.NyaaResult, error) {
+	indexers, err := indexer.Enabled(rule.Indexers)
+	if err != nil {
+		return nil, err
+	}
+	results, failed, err := indexer.Search(indexers, indexer.Query{Text: rule.Query})
+	for name, msg := range failed {
+		log.Printf("RSS poll [%s]: indexer %s: %s", rule.Name, name, msg)
+	}
+	return results, err
+}
+
+type fetchResult struct {
+	results []models.NyaaResult
+	err     error
+}
+
+// feedCache holds the feeds fetched during one poll cycle.
+type feedCache map[string]fetchResult
+
+// has reports whether the rule's feed was already fetched this cycle.
+func (c feedCache) has(rule models.RSSRule) bool {
+	_, ok := c[sourceKey(rule)]
+	return ok
+}
+
+// fetch returns the rule's feed, fetching it only on first use in the cycle.
+func (c feedCache) fetch(rule models.RSSRule) ([]models.NyaaResult, error) {
+	key := sourceKey(rule)
+	if r, ok := c[key]; ok {
+		return r.results, r.err
+	}
+	results, err := fetchSource(rule)
+	c[key] = fetchResult{results, err}
+	return results, err
+}
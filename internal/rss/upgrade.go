@@ -0,0 +1,156 @@
+package rss
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"link-anime/internal/models"
+	"link-anime/internal/parser"
+	"link-anime/internal/quality"
+)
+
+// Upgrade policies.
+const (
+	UpgradeNone     = "none"     // one release per episode
+	UpgradeRevision = "revision" // also take v2+ from the same group
+	UpgradeQuality  = "quality"  // also take a better-scoring release
+)
+
+func validUpgradePolicy(policy string) bool {
+	switch policy {
+	case UpgradeNone, UpgradeRevision, UpgradeQuality:
+		return true
+	}
+	return false
+}
+
+// Decisions for a release that passed a rule's filters. The last three are
+// also the status recorded for the match.
+const (
+	decisionDuplicate = "duplicate" // already recorded for this rule
+	decisionGrab      = "grab"
+	decisionSkip      = "skipped"   // episode already taken, and not an allowed upgrade
+	decisionReject    = "rejected"  // below the profile's minimum score
+	decisionWait      = "candidate" // held for the profile's delay window
+)
+
+// ruleProfile loads the rule's quality profile, or nil if it has none.
+func ruleProfile(rule models.RSSRule) (*models.QualityProfile, error) {
+	if rule.ProfileID == 0 {
+		return nil, nil
+	}
+	return quality.Get(rule.ProfileID)
+}
+
+// releaseScore ranks a release; higher is better. Without a profile,
+// resolution alone decides.
+func releaseScore(profile *models.QualityProfile, rel parser.Release) int {
+	if profile != nil {
+		return quality.Score(profile, rel)
+	}
+	return parser.ResolutionRank(rel.Resolution)
+}
+
+// candidates returns the results that pass the rule's filters as matches,
+// best first, so the preferred release of an episode is taken before its
+// lesser duplicates.
+func candidates(rule models.RSSRule, profile *models.QualityProfile, filter *ruleFilter, results []models.NyaaResult) []models.RSSMatch {
+	var matches []models.RSSMatch
+	for _, result := range results {
+		rel := parser.ParseRelease(result.Title)
+		if reason := filter.reject(result, rel); reason != "" {
+			continue
+		}
+		matches = append(matches, newMatch(rule, result, rel, releaseScore(profile, rel)))
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].Score != matches[b].Score {
+			return matches[a].Score > matches[b].Score
+		}
+		return matches[a].Revision > matches[b].Revision
+	})
+	return matches
+}
+
+// decide works out what to do with a filtered release, without side effects
+// beyond reading past matches. For upgrades it sets match.UpgradeOf.
+func decide(rule models.RSSRule, profile *models.QualityProfile, match *models.RSSMatch) (string, string, error) {
+	if isAlreadyMatched(rule.ID, match.Hash, hashTitle(match.Title)) {
+		return decisionDuplicate, "already matched", nil
+	}
+
+	if profile != nil && match.Score < profile.MinScore {
+		return decisionReject, fmt.Sprintf("score %d below minimum %d", match.Score, profile.MinScore), nil
+	}
+
+	if match.Episode == nil {
+		return decisionGrab, "", nil
+	}
+
+	// Skip duplicates of an episode already taken, unless this is an upgrade
+	prev, err := bestEpisodeMatch(rule.ID, *match.Season, *match.Episode)
+	if err != nil {
+		return "", "", err
+	}
+	if prev == nil {
+		if profile != nil && profile.DelayMinutes > 0 {
+			return decisionWait, fmt.Sprintf("waiting %d min for other releases", profile.DelayMinutes), nil
+		}
+		return decisionGrab, "", nil
+	}
+	if grab, reason := upgradeDecision(rule.UpgradePolicy, prev, *match); !grab {
+		return decisionSkip, reason, nil
+	}
+	match.UpgradeOf = &prev.ID
+	return decisionGrab, fmt.Sprintf("upgrade of %s", prev.Title), nil
+}
+
+// newMatch builds the match record for a result, including its episode
+// identity. Batches and movies carry no episode and are deduped by hash only.
+func newMatch(rule models.RSSRule, result models.NyaaResult, rel parser.Release, score int) models.RSSMatch {
+	m := models.RSSMatch{
+		RuleID:     rule.ID,
+		RuleName:   rule.Name,
+		Title:      result.Title,
+		Hash:       matchKey(result),
+		InfoHash:   result.InfoHash,
+		Resolution: rel.Resolution,
+		Group:      rel.Group,
+		Revision:   rel.Revision,
+		Score:      score,
+		Magnet:     result.Magnet,
+	}
+
+	if rule.MediaType != "movie" && rel.Episode != nil && !rel.Batch {
+		season := rule.Season
+		if rel.Season != nil {
+			season = *rel.Season
+		}
+		m.Season = &season
+		m.Episode = rel.Episode
+	}
+
+	return m
+}
+
+// upgradeDecision reports whether a candidate should be grabbed given the best
+// release already taken for its episode, and if not, why.
+func upgradeDecision(policy string, prev *models.RSSMatch, cand models.RSSMatch) (bool, string) {
+	if prev == nil {
+		return true, ""
+	}
+
+	switch policy {
+	case UpgradeRevision:
+		if cand.Revision > prev.Revision && strings.EqualFold(cand.Group, prev.Group) && cand.Score >= prev.Score {
+			return true, ""
+		}
+	case UpgradeQuality:
+		if cand.Score > prev.Score || (cand.Score == prev.Score && cand.Revision > prev.Revision) {
+			return true, ""
+		}
+	}
+
+	return false, fmt.Sprintf("episode already grabbed: %s", prev.Title)
+}
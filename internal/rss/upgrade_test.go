@@ -0,0 +1,90 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+
+	"link-anime/internal/models"
+)
+
+func TestUpgradeDecision(t *testing.T) {
+	prev := &models.RSSMatch{Title: "[Grp] Show - 01 [1080p]", Group: "Grp", Revision: 1, Score: 10}
+
+	tests := []struct {
+		name   string
+		policy string
+		cand   models.RSSMatch
+		want   bool
+	}{
+		{"none never upgrades", UpgradeNone, models.RSSMatch{Group: "Grp", Revision: 2, Score: 20}, false},
+		{"revision from same group", UpgradeRevision, models.RSSMatch{Group: "grp", Revision: 2, Score: 10}, true},
+		{"revision from another group", UpgradeRevision, models.RSSMatch{Group: "Other", Revision: 2, Score: 10}, false},
+		{"revision with a lower score", UpgradeRevision, models.RSSMatch{Group: "Grp", Revision: 2, Score: 5}, false},
+		{"quality with a higher score", UpgradeQuality, models.RSSMatch{Group: "Other", Revision: 1, Score: 20}, true},
+		{"quality tie broken by revision", UpgradeQuality, models.RSSMatch{Group: "Other", Revision: 2, Score: 10}, true},
+		{"quality tie at same revision", UpgradeQuality, models.RSSMatch{Group: "Grp", Revision: 1, Score: 10}, false},
+		{"quality with a lower score", UpgradeQuality, models.RSSMatch{Group: "Grp", Revision: 3, Score: 5}, false},
+	}
+	if got, _ := upgradeDecision(UpgradeNone, nil, models.RSSMatch{}); !got {
+		t.Error("with no previous grab, upgradeDecision = false, want true")
+	}
+	for _, tt := range tests {
+		got, reason := upgradeDecision(tt.policy, prev, tt.cand)
+		if got != tt.want {
+			t.Errorf("%s: upgradeDecision = %v (%q), want %v", tt.name, got, reason, tt.want)
+		}
+		if !got && !strings.Contains(reason, prev.Title) {
+			t.Errorf("%s: reason %q doesn't name the previous release", tt.name, reason)
+		}
+	}
+}
+
+func TestDecide(t *testing.T) {
+	_, rule := retryFixture(t)
+	rule.UpgradePolicy = UpgradeQuality
+
+	season, ep1, ep2 := 1, 1, 2
+	taken := models.RSSMatch{RuleID: rule.ID, Title: "[Grp] Show - 01 [720p]", Hash: hashTitle("[Grp] Show - 01 [720p]"),
+		Status: "downloaded", Season: &season, Episode: &ep1, Group: "Grp", Revision: 1, Score: 5}
+	if err := InsertMatch(&taken); err != nil {
+		t.Fatal(err)
+	}
+
+	episode := func(title string, ep, score int) models.RSSMatch {
+		return models.RSSMatch{RuleID: rule.ID, Title: title, Hash: hashTitle(title),
+			Season: &season, Episode: &ep, Group: "Grp", Revision: 1, Score: score}
+	}
+	batch := func(title string) models.RSSMatch {
+		return models.RSSMatch{RuleID: rule.ID, Title: title, Hash: hashTitle(title), Score: 1}
+	}
+
+	tests := []struct {
+		name    string
+		match   models.RSSMatch
+		profile *models.QualityProfile
+		want    string
+		upgrade bool
+	}{
+		{"duplicate", episode(taken.Title, 1, 5), nil, decisionDuplicate, false},
+		{"worse release of a taken episode", episode("[Grp] Show - 01 [480p]", 1, 2), nil, decisionSkip, false},
+		{"better release of a taken episode", episode("[Grp] Show - 01 [1080p]", 1, 10), nil, decisionGrab, true},
+		{"new episode", episode("[Grp] Show - 02 [1080p]", ep2, 10), nil, decisionGrab, false},
+		{"new episode within a delay", episode("[Grp] Show - 02 [1080p]", ep2, 10), &models.QualityProfile{DelayMinutes: 30}, decisionWait, false},
+		{"below the minimum score", episode("[Grp] Show - 02 [480p]", ep2, 2), &models.QualityProfile{MinScore: 3}, decisionReject, false},
+		{"batch", batch("[Grp] Show - 01-12 [1080p]"), nil, decisionGrab, false},
+		{"movie", batch("[Grp] Show The Movie [1080p]"), &models.QualityProfile{DelayMinutes: 30}, decisionGrab, false},
+	}
+	for _, tt := range tests {
+		m := tt.match
+		got, reason, err := decide(rule, tt.profile, &m)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: decide = %s (%q), want %s", tt.name, got, reason, tt.want)
+		}
+		if tt.upgrade != (m.UpgradeOf != nil && *m.UpgradeOf == taken.ID) {
+			t.Errorf("%s: UpgradeOf = %v, want upgrade %v", tt.name, m.UpgradeOf, tt.upgrade)
+		}
+	}
+}
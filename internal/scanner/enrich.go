@@ -0,0 +1,217 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"link-anime/internal/models"
+	"link-anime/internal/parser"
+)
+
+// fileID identifies a file on disk independent of its path.
+// Two hardlinks to the same data share a fileID.
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// LibraryIndex is a snapshot of the library used to enrich download items.
+type LibraryIndex struct {
+	Shows  []string
+	Movies []string
+	inodes map[fileID]string // inode -> library path
+}
+
+// BuildLibraryIndex walks the media and movies directories, recording the
+// show/movie names and the inode of every video file.
+func BuildLibraryIndex(mediaDir, moviesDir string) *LibraryIndex {
+	idx := &LibraryIndex{inodes: make(map[fileID]string)}
+
+	if shows, err := ScanLibrary(mediaDir); err == nil {
+		for _, s := range shows {
+			idx.Shows = append(idx.Shows, s.Name)
+		}
+	}
+	if movies, err := ScanMovies(moviesDir); err == nil {
+		for _, m := range movies {
+			idx.Movies = append(idx.Movies, m.Name)
+		}
+	}
+
+	for _, dir := range []string{mediaDir, moviesDir} {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() || !IsVideo(info.Name()) {
+				return nil
+			}
+			if id, ok := statID(info); ok {
+				idx.inodes[id] = path
+			}
+			return nil
+		})
+	}
+
+	return idx
+}
+
+// IsLinked reports whether the file at path shares an inode with a library file.
+func (idx *LibraryIndex) IsLinked(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	id, ok := statID(info)
+	if !ok {
+		return false
+	}
+	_, found := idx.inodes[id]
+	return found
+}
+
+// EnrichDownload fills in the parse result, library match, suggested type
+// and link status of a download item.
+func EnrichDownload(item *models.DownloadItem, idx *LibraryIndex) {
+	parsed := parser.ParseReleaseName(item.Name)
+	item.Parsed = &models.ParseResult{Name: parsed.Name, Season: parsed.Season}
+
+	show := MatchName(parsed.Name, idx.Shows)
+	movie := MatchName(parsed.Name, idx.Movies)
+	switch {
+	case show != "":
+		item.MatchedShow = show
+	case movie != "":
+		item.MatchedShow = movie
+	}
+
+	item.SuggestedType = guessType(item, parsed, show, movie)
+
+	total := 0
+	linked := 0
+	walkVideos(item.Path, func(path string) {
+		total++
+		if idx.IsLinked(path) {
+			linked++
+		}
+	})
+	item.LinkedCount = linked
+	switch {
+	case total > 0 && linked == total:
+		item.LinkStatus = "full"
+	case linked > 0:
+		item.LinkStatus = "partial"
+	default:
+		item.LinkStatus = "none"
+	}
+}
+
+// MatchName returns the candidate that best matches name, or "" if none is
+// close enough. Comparison ignores case, punctuation and a trailing year.
+func MatchName(name string, candidates []string) string {
+	target := normalizeName(name)
+	if target == "" {
+		return ""
+	}
+	targetTokens := strings.Fields(target)
+
+	best := ""
+	bestScore := 0.0
+	for _, c := range candidates {
+		norm := normalizeName(c)
+		if norm == target {
+			return c
+		}
+		score := tokenOverlap(targetTokens, strings.Fields(norm))
+		if score > bestScore {
+			best = c
+			bestScore = score
+		}
+	}
+
+	if bestScore >= 0.6 {
+		return best
+	}
+	return ""
+}
+
+// --- helpers ---
+
+var (
+	reNameYear    = regexp.MustCompile(`\((?:19|20)\d{2}\)`)
+	reNamePunct   = regexp.MustCompile(`[^a-z0-9]+`)
+	reEpisodeMark = regexp.MustCompile(`(?i)(?:\s-\s\d{1,4}(?:v\d)?(?:\s|$)|\bEP?\s?\d{1,4}\b)`)
+)
+
+func normalizeName(name string) string {
+	name = strings.ToLower(name)
+	name = reNameYear.ReplaceAllString(name, "")
+	name = reNamePunct.ReplaceAllString(name, " ")
+	return strings.TrimSpace(name)
+}
+
+// tokenOverlap returns the Jaccard similarity of two token sets.
+func tokenOverlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]bool, len(a))
+	for _, t := range a {
+		set[t] = true
+	}
+	inter := 0
+	union := len(set)
+	seen := make(map[string]bool, len(b))
+	for _, t := range b {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		if set[t] {
+			inter++
+		} else {
+			union++
+		}
+	}
+	return float64(inter) / float64(union)
+}
+
+func guessType(item *models.DownloadItem, parsed parser.Result, show, movie string) string {
+	switch {
+	case parsed.Season != nil:
+		return "series"
+	case show != "":
+		return "series"
+	case movie != "":
+		return "movie"
+	case item.VideoCount > 1:
+		return "series"
+	case item.VideoCount == 1 && !reEpisodeMark.MatchString(item.Name):
+		return "movie"
+	}
+	return "series"
+}
+
+// walkVideos calls fn for every video file at or below path.
+func walkVideos(path string, fn func(string)) {
+	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() && IsVideo(info.Name()) {
+			fn(p)
+		}
+		return nil
+	})
+}
+
+func statID(info os.FileInfo) (fileID, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"link-anime/internal/models"
+	"link-anime/internal/parser"
+)
+
+func TestMatchName(t *testing.T) {
+	candidates := []string{"Frieren", "Your Name (2016)", "Attack on Titan", "Spy x Family"}
+	tests := []struct {
+		name, want string
+	}{
+		{"frieren", "Frieren"},                       // case
+		{"Your Name", "Your Name (2016)"},            // year ignored
+		{"Spy.x.Family", "Spy x Family"},             // punctuation ignored
+		{"Attack on Titan Final", "Attack on Titan"}, // 3 of 4 tokens
+		{"Sousou no Frieren", ""},                    // 1 of 3 tokens
+		{"Attack on Mars", ""},                       // 2 of 4 tokens
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := MatchName(tt.name, candidates); got != tt.want {
+			t.Errorf("MatchName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// The threshold is a Jaccard similarity of 0.6
+	if got := MatchName("a b c d", []string{"a b c e"}); got != "a b c e" {
+		t.Errorf("3 of 5 tokens: got %q, want a match", got)
+	}
+	if got := MatchName("a b c d", []string{"a b e f"}); got != "" {
+		t.Errorf("2 of 6 tokens: got %q, want no match", got)
+	}
+}
+
+func TestGuessType(t *testing.T) {
+	season := 2
+	tests := []struct {
+		name        string
+		item        models.DownloadItem
+		parsed      parser.Result
+		show, movie string
+		want        string
+	}{
+		{"season in name", models.DownloadItem{VideoCount: 1}, parser.Result{Season: &season}, "", "", "series"},
+		{"library show", models.DownloadItem{VideoCount: 1}, parser.Result{}, "Show", "", "series"},
+		{"library movie", models.DownloadItem{VideoCount: 5}, parser.Result{}, "", "Movie", "movie"},
+		{"several videos", models.DownloadItem{VideoCount: 12}, parser.Result{}, "", "", "series"},
+		{"single video", models.DownloadItem{Name: "Some Film (2020) [1080p].mkv", VideoCount: 1}, parser.Result{}, "", "", "movie"},
+		{"single episode", models.DownloadItem{Name: "[Grp] Show - 05 [1080p].mkv", VideoCount: 1}, parser.Result{}, "", "", "series"},
+		{"no videos", models.DownloadItem{}, parser.Result{}, "", "", "series"},
+	}
+	for _, tt := range tests {
+		if got := guessType(&tt.item, tt.parsed, tt.show, tt.movie); got != tt.want {
+			t.Errorf("%s: guessType = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEnrichDownload(t *testing.T) {
+	root := t.TempDir()
+	media, movies := filepath.Join(root, "media"), filepath.Join(root, "movies")
+	release := filepath.Join(root, "downloads", "[Grp] Frieren (01-02) [1080p]")
+	ep1 := filepath.Join(release, "[Grp] Frieren - 01 [1080p].mkv")
+	ep2 := filepath.Join(release, "[Grp] Frieren - 02 [1080p].mkv")
+	for _, path := range []string{ep1, ep2} {
+		writeFile(t, path)
+	}
+	season := filepath.Join(media, "Frieren", "Season 1")
+	os.MkdirAll(season, 0755)
+	os.MkdirAll(movies, 0755)
+	link := func(src string) {
+		t.Helper()
+		if err := os.Link(src, filepath.Join(season, filepath.Base(src))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	item := models.DownloadItem{Name: filepath.Base(release), Path: release, IsDir: true, VideoCount: 2}
+	EnrichDownload(&item, BuildLibraryIndex(media, movies))
+	if item.LinkStatus != "none" || item.LinkedCount != 0 {
+		t.Errorf("nothing linked: %s with %d linked", item.LinkStatus, item.LinkedCount)
+	}
+	if item.MatchedShow != "Frieren" || item.SuggestedType != "series" {
+		t.Errorf("matched %q as %q, want Frieren as series", item.MatchedShow, item.SuggestedType)
+	}
+
+	// A copy with the same content isn't linked; only a shared inode counts
+	writeFile(t, filepath.Join(season, "copy of episode 2.mkv"))
+	link(ep1)
+	idx := BuildLibraryIndex(media, movies)
+	if !idx.IsLinked(ep1) || idx.IsLinked(ep2) || idx.IsLinked(filepath.Join(release, "missing.mkv")) {
+		t.Errorf("IsLinked: ep1 %v, ep2 %v; want only ep1", idx.IsLinked(ep1), idx.IsLinked(ep2))
+	}
+	EnrichDownload(&item, idx)
+	if item.LinkStatus != "partial" || item.LinkedCount != 1 {
+		t.Errorf("one linked: %s with %d linked", item.LinkStatus, item.LinkedCount)
+	}
+
+	link(ep2)
+	EnrichDownload(&item, BuildLibraryIndex(media, movies))
+	if item.LinkStatus != "full" || item.LinkedCount != 2 {
+		t.Errorf("both linked: %s with %d linked", item.LinkStatus, item.LinkedCount)
+	}
+}
+
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+
+	"link-anime/internal/parser"
+)
+
+// LibraryEpisodes returns the episode numbers present in a show's season
+// directory. The show directory is found by name the same way downloads are
+// matched to shows, so minor naming differences don't hide episodes.
+func LibraryEpisodes(mediaDir, show string, season int) map[int]bool {
+	have := make(map[int]bool)
+
+	showDir := filepath.Join(mediaDir, show)
+	if _, err := os.Stat(showDir); err != nil {
+		shows, _ := ScanLibrary(mediaDir)
+		names := make([]string, len(shows))
+		for i, s := range shows {
+			names[i] = s.Name
+		}
+		match := MatchName(show, names)
+		if match == "" {
+			return have
+		}
+		showDir = filepath.Join(mediaDir, match)
+	}
+
+	entries, err := os.ReadDir(showDir)
+	if err != nil {
+		return have
+	}
+	for _, e := range entries {
+		if e.IsDir() && parseSeasonDir(e.Name()) == season {
+			walkVideos(filepath.Join(showDir, e.Name()), func(path string) {
+				rel := parser.ParseRelease(filepath.Base(path))
+				if rel.Episode == nil {
+					return
+				}
+				last := *rel.Episode
+				if rel.EpisodeEnd != nil && *rel.EpisodeEnd > last {
+					last = *rel.EpisodeEnd
+				}
+				for ep := *rel.Episode; ep <= last; ep++ {
+					have[ep] = true
+				}
+			})
+		}
+	}
+	return have
+}
+
+// MissingEpisodes returns the episodes from 1 to upTo that are not in have.
+func MissingEpisodes(have map[int]bool, upTo int) []int {
+	var missing []int
+	for ep := 1; ep <= upTo; ep++ {
+		if !have[ep] {
+			missing = append(missing, ep)
+		}
+	}
+	return missing
+}
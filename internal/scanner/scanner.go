@@ -129,8 +129,13 @@ func ScanMovies(moviesDir string) ([]models.Movie, error) {
 	return movies, nil
 }
 
-// ScanDownloads returns all downloadable items (folders + loose video files).
-func ScanDownloads(downloadDir string) ([]models.DownloadItem, error) {
+// DownloadFilter decides whether a download item is listed. It may also
+// annotate the item (e.g. set its State). A nil filter keeps everything.
+type DownloadFilter func(item *models.DownloadItem) bool
+
+// ScanDownloads returns all downloadable items (folders + loose video files)
+// accepted by filter.
+func ScanDownloads(downloadDir string, filter DownloadFilter) ([]models.DownloadItem, error) {
 	entries, err := os.ReadDir(downloadDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -143,29 +148,45 @@ func ScanDownloads(downloadDir string) ([]models.DownloadItem, error) {
 	for _, entry := range entries {
 		fullPath := filepath.Join(downloadDir, entry.Name())
 
+		var item models.DownloadItem
 		if entry.IsDir() {
-			vc := countVideos(fullPath)
-			size := dirSize(fullPath)
-			items = append(items, models.DownloadItem{
-				Name:       entry.Name(),
-				Path:       fullPath,
-				IsDir:      true,
-				VideoCount: vc,
-				Size:       size,
-			})
-		} else if IsVideo(entry.Name()) {
-			info, err := entry.Info()
-			if err != nil {
-				continue
+			item = models.DownloadItem{
+				Name:  entry.Name(),
+				Path:  fullPath,
+				IsDir: true,
 			}
-			items = append(items, models.DownloadItem{
+		} else if IsVideo(entry.Name()) {
+			item = models.DownloadItem{
 				Name:       entry.Name(),
 				Path:       fullPath,
 				IsDir:      false,
 				VideoCount: 1,
-				Size:       info.Size(),
-			})
+			}
+		} else {
+			continue
+		}
+
+		// os.Stat, like FileIdentity, so stored states match what the scan sees
+		info, err := os.Stat(fullPath)
+		if err != nil {
+			continue
+		}
+		if id, ok := statID(info); ok {
+			item.Device, item.Inode = id.dev, id.ino
+		}
+
+		if filter != nil && !filter(&item) {
+			continue
+		}
+
+		// Size and video count need a full walk, so only compute them for kept items
+		if item.IsDir {
+			item.VideoCount = countVideos(fullPath)
+			item.Size = dirSize(fullPath)
+		} else {
+			item.Size = info.Size()
 		}
+		items = append(items, item)
 	}
 
 	sort.Slice(items, func(i, j int) bool {
@@ -175,6 +196,19 @@ func ScanDownloads(downloadDir string) ([]models.DownloadItem, error) {
 	return items, nil
 }
 
+// FileIdentity returns the device and inode of path, or zeros if it can't be stat'd.
+func FileIdentity(path string) (dev, ino uint64) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0
+	}
+	id, ok := statID(info)
+	if !ok {
+		return 0, 0
+	}
+	return id.dev, id.ino
+}
+
 // LibrarySize calculates total video file size across media + movies dirs.
 func LibrarySize(mediaDir, moviesDir string) int64 {
 	var total int64
@@ -218,6 +252,12 @@ func CountVideosIn(dir string) int {
 	return countVideos(dir)
 }
 
+// SeasonDirNumber returns the season a directory name like "Season 2" or
+// "S02" stands for, or -1 if it isn't a season directory.
+func SeasonDirNumber(name string) int {
+	return parseSeasonDir(name)
+}
+
 // --- helpers ---
 
 var reSeasonDir = regexp.MustCompile(`(?i)^(?:season\s*0*(\d+)|s0*(\d+))$`)
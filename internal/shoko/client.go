@@ -8,13 +8,15 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"link-anime/internal/httpx"
 )
 
 // Client is a Shoko Server API client.
 type Client struct {
 	baseURL string
 	apiKey  string
-	client  *http.Client
+	client  *httpx.Client
 }
 
 // New creates a new Shoko client.
@@ -22,7 +24,7 @@ func New(baseURL, apiKey string) *Client {
 	return &Client{
 		baseURL: strings.TrimRight(baseURL, "/"),
 		apiKey:  apiKey,
-		client:  &http.Client{Timeout: 30 * time.Second},
+		client:  httpx.New(httpx.Options{Name: "shoko", Timeout: 30 * time.Second}),
 	}
 }
 
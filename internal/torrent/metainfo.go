@@ -0,0 +1,134 @@
+// Package torrent reads .torrent metainfo and predicts how a torrent's files
+// would be linked into the library.
+package torrent
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"link-anime/internal/bencode"
+)
+
+// File is one file listed in a torrent.
+type File struct {
+	Path string // relative to the torrent root, "/"-separated
+	Size int64
+}
+
+// Metainfo is the part of a .torrent file the preview needs.
+type Metainfo struct {
+	Name        string
+	InfoHash    string // lowercase hex SHA-1 of the info dictionary
+	PieceLength int64
+	TotalSize   int64
+	Single      bool // a single-file torrent, whose one file is Name itself
+	Files       []File
+}
+
+// Parse decodes a .torrent file. Padding files are left out.
+func Parse(data []byte) (*Metainfo, error) {
+	v, err := bencode.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode torrent: %w", err)
+	}
+	top, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("decode torrent: not a dictionary")
+	}
+	info, ok := top["info"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("decode torrent: missing info dictionary")
+	}
+	raw, err := bencode.RawField(data, "info")
+	if err != nil {
+		return nil, fmt.Errorf("decode torrent: %w", err)
+	}
+	sum := sha1.Sum(raw)
+
+	m := &Metainfo{
+		Name:     utf8Field(info, "name"),
+		InfoHash: hex.EncodeToString(sum[:]),
+	}
+	m.PieceLength, _ = info["piece length"].(int64)
+	if m.Name == "" {
+		return nil, fmt.Errorf("decode torrent: missing name")
+	}
+	if strings.ContainsAny(m.Name, `/\`) || m.Name == "." || m.Name == ".." {
+		return nil, fmt.Errorf("decode torrent: invalid name %q", m.Name)
+	}
+
+	if length, ok := info["length"].(int64); ok {
+		m.Single = true
+		m.Files = []File{{Path: m.Name, Size: length}}
+		m.TotalSize = length
+		return m, nil
+	}
+
+	list, ok := info["files"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("decode torrent: no file list (v2-only torrents are not supported)")
+	}
+	for _, item := range list {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("decode torrent: bad file entry")
+		}
+		if attr, _ := entry["attr"].(string); strings.Contains(attr, "p") {
+			continue
+		}
+		p, err := filePath(entry)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(p, ".pad/") || strings.HasPrefix(p[strings.LastIndex(p, "/")+1:], "_____padding_file") {
+			continue
+		}
+		size, _ := entry["length"].(int64)
+		if size < 0 {
+			return nil, fmt.Errorf("decode torrent: negative length for %q", p)
+		}
+		m.Files = append(m.Files, File{Path: p, Size: size})
+		m.TotalSize += size
+	}
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	return m, nil
+}
+
+// utf8Field prefers the ".utf-8" variant of a string field when present.
+func utf8Field(dict map[string]interface{}, key string) string {
+	if s, ok := dict[key+".utf-8"].(string); ok && s != "" {
+		return s
+	}
+	s, _ := dict[key].(string)
+	return s
+}
+
+// filePath joins a file entry's path components, refusing ones that would
+// escape the torrent root.
+func filePath(entry map[string]interface{}) (string, error) {
+	parts, ok := entry["path.utf-8"].([]interface{})
+	if !ok {
+		parts, _ = entry["path"].([]interface{})
+	}
+	var clean []string
+	for _, part := range parts {
+		s, ok := part.(string)
+		if !ok {
+			return "", fmt.Errorf("decode torrent: bad path component")
+		}
+		if s == "" || s == "." {
+			continue
+		}
+		if s == ".." || strings.ContainsAny(s, `/\`) {
+			return "", fmt.Errorf("decode torrent: unsafe path component %q", s)
+		}
+		clean = append(clean, s)
+	}
+	if len(clean) == 0 {
+		return "", fmt.Errorf("decode torrent: file with empty path")
+	}
+	return strings.Join(clean, "/"), nil
+}
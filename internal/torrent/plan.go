@@ -0,0 +1,124 @@
+package torrent
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"link-anime/internal/models"
+	"link-anime/internal/parser"
+	"link-anime/internal/scanner"
+)
+
+var (
+	subtitleExts = map[string]bool{".ass": true, ".ssa": true, ".srt": true, ".vtt": true, ".sub": true, ".idx": true, ".sup": true}
+	fontExts     = map[string]bool{".ttf": true, ".otf": true, ".ttc": true, ".woff": true, ".woff2": true}
+)
+
+// Kind classifies a file name as "video", "subtitle", "font" or "other".
+func Kind(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	switch {
+	case scanner.IsVideo(name):
+		return "video"
+	case subtitleExts[ext]:
+		return "subtitle"
+	case fontExts[ext]:
+		return "font"
+	default:
+		return "other"
+	}
+}
+
+// Plan predicts what linking the torrent once downloaded would do, following
+// the linker's rules: only video files are linked, series take season
+// subfolders of the torrent root when there are any and otherwise its flat
+// files, and movies take the flat files. Empty Type, Name and Season in req
+// are filled from the torrent name.
+func Plan(m *Metainfo, req models.LinkRequest) models.TorrentPreview {
+	parsed := parser.ParseReleaseName(m.Name)
+	if req.Type == "" {
+		req.Type = "series"
+	}
+	if req.Name == "" {
+		req.Name = parsed.Name
+	}
+	if req.Season == 0 {
+		req.Season = 1
+		if parsed.Season != nil {
+			req.Season = *parsed.Season
+		}
+	}
+
+	preview := models.TorrentPreview{
+		Name:        m.Name,
+		InfoHash:    m.InfoHash,
+		TotalSize:   m.TotalSize,
+		PieceLength: m.PieceLength,
+		Type:        req.Type,
+		ShowName:    req.Name,
+		Files:       []models.TorrentPreviewFile{},
+	}
+	if req.Type == "series" {
+		preview.Season = req.Season
+	}
+
+	// Season subfolders replace the default season, as in the linker
+	seasonDirs := map[string]int{}
+	if req.Type == "series" && !m.Single {
+		for _, f := range m.Files {
+			if i := strings.Index(f.Path, "/"); i > 0 {
+				if n := scanner.SeasonDirNumber(f.Path[:i]); n >= 0 {
+					seasonDirs[f.Path[:i]] = n
+				}
+			}
+		}
+	}
+
+	for _, f := range m.Files {
+		pf := models.TorrentPreviewFile{Path: f.Path, Size: f.Size, Kind: Kind(f.Path)}
+		switch pf.Kind {
+		case "subtitle":
+			preview.HasSubs = true
+		case "font":
+			preview.HasFonts = true
+		}
+
+		dir, file := path.Split(f.Path)
+		dir = strings.TrimSuffix(dir, "/")
+		season := req.Season
+		switch {
+		case pf.Kind != "video":
+			pf.Reason = fmt.Sprintf("%s file, not a video", pf.Kind)
+		case len(seasonDirs) > 0:
+			n, ok := seasonDirs[dir]
+			if !ok {
+				pf.Reason = "not in a season folder"
+				break
+			}
+			season = n
+			pf.Link = true
+		case dir != "":
+			pf.Reason = fmt.Sprintf("in subfolder %q", dir)
+		default:
+			pf.Link = true
+		}
+
+		if pf.Link {
+			if req.Type == "movie" {
+				pf.Dest = path.Join(req.Name, file)
+			} else {
+				s := season
+				pf.Season = &s
+				pf.Dest = path.Join(req.Name, fmt.Sprintf("Season %d", season), file)
+				pf.Episode = parser.ParseRelease(file).Episode
+			}
+			preview.Linked++
+		} else {
+			preview.Skipped++
+		}
+		preview.Files = append(preview.Files, pf)
+	}
+
+	return preview
+}
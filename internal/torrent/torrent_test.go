@@ -0,0 +1,122 @@
+package torrent
+
+import (
+	"testing"
+
+	"link-anime/internal/bencode"
+	"link-anime/internal/models"
+)
+
+func multiFile(name string, files map[string]int64) []byte {
+	var list []interface{}
+	for p, size := range files {
+		var parts []interface{}
+		for _, c := range splitPath(p) {
+			parts = append(parts, c)
+		}
+		list = append(list, map[string]interface{}{"length": size, "path": parts})
+	}
+	data, err := bencode.Encode(map[string]interface{}{
+		"announce": "http://tracker.example/announce",
+		"info": map[string]interface{}{
+			"name":         name,
+			"piece length": int64(1 << 20),
+			"pieces":       "",
+			"files":        list,
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func splitPath(p string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(p); i++ {
+		if p[i] == '/' {
+			parts = append(parts, p[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, p[start:])
+}
+
+func TestParse(t *testing.T) {
+	data := multiFile("[Group] Show (BD 1080p)", map[string]int64{
+		"Show - 01.mkv":      100,
+		"Show - 02.mkv":      200,
+		"Fonts/font.ttf":     5,
+		".pad/0":             3,
+		"Subs/Show - 01.ass": 7,
+	})
+	m, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "[Group] Show (BD 1080p)" || m.Single || m.PieceLength != 1<<20 {
+		t.Errorf("unexpected metainfo %+v", m)
+	}
+	if len(m.Files) != 4 || m.TotalSize != 312 {
+		t.Errorf("files = %+v, total %d", m.Files, m.TotalSize)
+	}
+	if len(m.InfoHash) != 40 {
+		t.Errorf("info hash %q", m.InfoHash)
+	}
+
+	if _, err := Parse(multiFile("x", map[string]int64{"../escape.mkv": 1})); err == nil {
+		t.Error("path with .. was accepted")
+	}
+}
+
+func TestPlan(t *testing.T) {
+	flat, _ := Parse(multiFile("[Group] Show S02 (1080p)", map[string]int64{
+		"[Group] Show - 13.mkv":    100,
+		"Extras/NCOP.mkv":          10,
+		"Fonts/font.ttf":           5,
+		"[Group] Show - 13.en.ass": 1,
+	}))
+	p := Plan(flat, models.LinkRequest{})
+	if p.ShowName != "Show" || p.Season != 2 || p.Type != "series" {
+		t.Fatalf("defaults = %q season %d %s", p.ShowName, p.Season, p.Type)
+	}
+	if p.Linked != 1 || p.Skipped != 3 || !p.HasSubs || !p.HasFonts {
+		t.Errorf("linked %d skipped %d subs %v fonts %v", p.Linked, p.Skipped, p.HasSubs, p.HasFonts)
+	}
+	for _, f := range p.Files {
+		if f.Link && (f.Dest != "Show/Season 2/[Group] Show - 13.mkv" || f.Episode == nil || *f.Episode != 13) {
+			t.Errorf("linked file %+v", f)
+		}
+		if f.Path == "Extras/NCOP.mkv" && f.Reason != `in subfolder "Extras"` {
+			t.Errorf("extras reason %q", f.Reason)
+		}
+	}
+
+	multi, _ := Parse(multiFile("Show Complete", map[string]int64{
+		"Season 1/Show S01E01.mkv": 1,
+		"S02/Show S02E01.mkv":      1,
+		"Show OVA.mkv":             1,
+	}))
+	p = Plan(multi, models.LinkRequest{Name: "Show"})
+	if p.Linked != 2 || p.Skipped != 1 {
+		t.Fatalf("linked %d skipped %d", p.Linked, p.Skipped)
+	}
+	for _, f := range p.Files {
+		switch f.Path {
+		case "S02/Show S02E01.mkv":
+			if f.Dest != "Show/Season 2/Show S02E01.mkv" {
+				t.Errorf("dest %q", f.Dest)
+			}
+		case "Show OVA.mkv":
+			if f.Link || f.Reason != "not in a season folder" {
+				t.Errorf("root file %+v", f)
+			}
+		}
+	}
+
+	p = Plan(multi, models.LinkRequest{Type: "movie", Name: "Film"})
+	if p.Linked != 1 || p.Files[2].Dest != "Film/Show OVA.mkv" {
+		t.Errorf("movie plan %+v", p.Files)
+	}
+}